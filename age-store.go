@@ -0,0 +1,418 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"filippo.io/age"
+)
+
+// storage backends
+const (
+	appBackendGopass = "gopass"
+	appBackendAge    = "age"
+)
+
+var appBackends = []string{appBackendGopass, appBackendAge}
+
+// age backend defaults, home directory is expanded at runtime
+const appAgeDirDefault = "~/.local/share/" + appName + "/keys"
+
+var appAgeIdentityDefault = appConfigPath("age-identity.txt")
+
+// age backend files, every file is encrypted to recipients of identity file:
+//
+// <dir>/<key>/password.age, <dir>/<key>/ssh-key.age, <dir>/<key>/ssh-key.pub.age - ssh-key parts
+//
+// <dir>/<key>/metadata.age - ssh-key metadata as json object
+//
+// <dir>/<prefix>/<id>.age - known_hosts entries
+const (
+	ageFileExtension = ".age"
+	ageFileMetadata  = "metadata"
+)
+
+// ageKeyStore is keyStore implementation on age encrypted directory tree
+type ageKeyStore struct {
+	dir        string
+	identities []age.Identity
+	recipients []age.Recipient
+	tx         *ageTx
+
+	keysChanged bool
+}
+
+// ageTx holds original content of files changed in transaction, nil content means file did not exist
+type ageTx struct {
+	files   []string
+	content map[string][]byte
+}
+
+// newAgeKeyStore opens age key directory, X25519 identities of identity file are also recipients
+func newAgeKeyStore(dir, identityFile string) (as *ageKeyStore, err error) {
+	if dir == "" {
+		return nil, newUsageError("age key directory must be set")
+	}
+
+	f, err := os.Open(identityFile)
+	if err != nil {
+		return nil, fmt.Errorf("cannot open age identity file: %w", err)
+	}
+	defer f.Close()
+
+	identities, err := age.ParseIdentities(f)
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse age identity file '%s': %w", identityFile, err)
+	}
+
+	as = &ageKeyStore{dir: dir, identities: identities}
+	for _, i := range identities {
+		if x, ok := i.(*age.X25519Identity); ok {
+			as.recipients = append(as.recipients, x.Recipient())
+		}
+	}
+
+	if len(as.recipients) == 0 {
+		return nil, fmt.Errorf("age identity file '%s' has no X25519 identities", identityFile)
+	}
+
+	return as, nil
+}
+
+func (as *ageKeyStore) path(name string) string {
+	return filepath.Join(as.dir, filepath.FromSlash(name)+ageFileExtension)
+}
+
+func (as *ageKeyStore) read(name string) ([]byte, error) {
+	f, err := os.Open(as.path(name))
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	defer f.Close()
+
+	r, err := age.Decrypt(f, as.identities...)
+	if err != nil {
+		return nil, fmt.Errorf("cannot decrypt '%s': %w", name, err)
+	}
+	return io.ReadAll(r)
+}
+
+// backup saves original file content before first change in transaction
+func (as *ageKeyStore) backup(name string) error {
+	if as.tx == nil {
+		return nil
+	}
+	if _, ok := as.tx.content[name]; ok {
+		return nil
+	}
+
+	data, err := as.read(name)
+	if err != nil && err != ErrNotFound {
+		return err
+	}
+
+	as.tx.files = append(as.tx.files, name)
+	as.tx.content[name] = data
+	return nil
+}
+
+func (as *ageKeyStore) write(name string, data []byte) (err error) {
+	if err = as.backup(name); err != nil {
+		return
+	}
+
+	var buf bytes.Buffer
+	w, err := age.Encrypt(&buf, as.recipients...)
+	if err != nil {
+		return
+	}
+	if _, err = w.Write(data); err != nil {
+		return
+	}
+	if err = w.Close(); err != nil {
+		return
+	}
+
+	var p = as.path(name)
+	if err = os.MkdirAll(filepath.Dir(p), 0700); err != nil {
+		return
+	}
+
+	// file is replaced atomically
+	tmp, err := os.CreateTemp(filepath.Dir(p), ".tmp-")
+	if err != nil {
+		return
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err = tmp.Write(buf.Bytes()); err != nil {
+		tmp.Close()
+		return
+	}
+	if err = tmp.Close(); err != nil {
+		return
+	}
+
+	if err = os.Rename(tmp.Name(), p); err != nil {
+		return
+	}
+
+	as.keysChanged = true
+	return nil
+}
+
+// remove deletes file and its empty parent directories
+func (as *ageKeyStore) remove(name string) (err error) {
+	if err = as.backup(name); err != nil {
+		return
+	}
+
+	var p = as.path(name)
+	err = os.Remove(p)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return
+	}
+
+	as.keysChanged = true
+	for dir := filepath.Dir(p); dir != filepath.Clean(as.dir); dir = filepath.Dir(dir) {
+		if os.Remove(dir) != nil {
+			break
+		}
+	}
+	return nil
+}
+
+// walk returns names of files under prefix
+func (as *ageKeyStore) walk(prefix string) (names []string, err error) {
+	var root = filepath.Join(as.dir, filepath.FromSlash(prefix))
+	err = filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+
+		if d.IsDir() || !strings.HasSuffix(p, ageFileExtension) {
+			return nil
+		}
+
+		rel, err := filepath.Rel(as.dir, strings.TrimSuffix(p, ageFileExtension))
+		if err != nil {
+			return err
+		}
+		names = append(names, filepath.ToSlash(rel))
+		return nil
+	})
+	return
+}
+
+func isAgeKeyPart(name string) bool {
+	switch filepath.Base(name) {
+	case gopassKeySuffixPassword, gopassKeySuffixPrivateKey, gopassKeySuffixPublicKey, ageFileMetadata:
+		return true
+	}
+	return false
+}
+
+func (as *ageKeyStore) partName(key, part string) (string, error) {
+	if !isAgeKeyPart(part) || part == ageFileMetadata {
+		return "", fmt.Errorf("unknown ssh-key part '%s'", part)
+	}
+	return filepath.Join(key, part), nil
+}
+
+func (as *ageKeyStore) list(prefix string) (ll []string, err error) {
+	names, err := as.walk(prefix)
+	if err != nil {
+		return
+	}
+
+	var m = make(map[string]bool)
+	for _, name := range names {
+		if !isAgeKeyPart(name) {
+			continue
+		}
+
+		var p = filepath.Dir(name)
+		if prefix != "" {
+			p = strings.TrimPrefix(p, prefix+"/")
+		}
+
+		if !m[p] {
+			m[p] = true
+			ll = append(ll, p)
+		}
+	}
+
+	sort.Strings(ll)
+	return
+}
+
+func (as *ageKeyStore) getPassword(key string) (string, error) {
+	data, err := as.read(filepath.Join(key, gopassKeySuffixPassword))
+	return string(data), err
+}
+
+func (as *ageKeyStore) setPassword(key, password string) error {
+	return as.write(filepath.Join(key, gopassKeySuffixPassword), []byte(password))
+}
+
+func (as *ageKeyStore) delPassword(key string) error {
+	return as.remove(filepath.Join(key, gopassKeySuffixPassword))
+}
+
+func (as *ageKeyStore) getPrivateSSHKey(key string) ([]byte, error) {
+	return as.read(filepath.Join(key, gopassKeySuffixPrivateKey))
+}
+
+func (as *ageKeyStore) setPrivateSSHKey(key string, data []byte) error {
+	return as.write(filepath.Join(key, gopassKeySuffixPrivateKey), data)
+}
+
+func (as *ageKeyStore) delPrivateSSHKey(key string) error {
+	return as.remove(filepath.Join(key, gopassKeySuffixPrivateKey))
+}
+
+func (as *ageKeyStore) getPublicSSHKey(key string) ([]byte, error) {
+	return as.read(filepath.Join(key, gopassKeySuffixPublicKey))
+}
+
+func (as *ageKeyStore) setPublicSSHKey(key string, data []byte) error {
+	return as.write(filepath.Join(key, gopassKeySuffixPublicKey), data)
+}
+
+func (as *ageKeyStore) delPublicSSHKey(key string) error {
+	err := as.remove(filepath.Join(key, gopassKeySuffixPublicKey))
+	if err != nil {
+		return err
+	}
+	return as.remove(filepath.Join(key, ageFileMetadata))
+}
+
+func (as *ageKeyStore) getMetadata(key string) (o map[string]string, err error) {
+	o = make(map[string]string)
+	data, err := as.read(filepath.Join(key, ageFileMetadata))
+	if err != nil {
+		if err == ErrNotFound {
+			return o, nil
+		}
+		return
+	}
+
+	err = json.Unmarshal(data, &o)
+	return
+}
+
+func (as *ageKeyStore) setMetadata(key string, meta map[string]string) error {
+	_, err := as.getPublicSSHKey(key)
+	if err != nil {
+		return err
+	}
+
+	existing, err := as.getMetadata(key)
+	if err != nil {
+		return err
+	}
+
+	for k, v := range meta {
+		if v == "" {
+			delete(existing, k)
+			continue
+		}
+		existing[k] = v
+	}
+
+	if len(existing) == 0 {
+		return as.remove(filepath.Join(key, ageFileMetadata))
+	}
+
+	data, err := json.Marshal(existing)
+	if err != nil {
+		return err
+	}
+	return as.write(filepath.Join(key, ageFileMetadata), data)
+}
+
+func (as *ageKeyStore) getKnownHosts(prefix string) (o map[string][]knownHostEntry, err error) {
+	names, err := as.walk(prefix)
+	if err != nil {
+		return
+	}
+
+	o = make(map[string][]knownHostEntry)
+	for _, name := range names {
+		if isAgeKeyPart(name) {
+			continue
+		}
+
+		data, err := as.read(name)
+		if err != nil {
+			return nil, err
+		}
+
+		entries, err := parseKnownHosts(data)
+		if err != nil {
+			return nil, fmt.Errorf("cannot parse known hosts file '%s': %w", name, err)
+		}
+		o[strings.TrimPrefix(name, prefix+"/")] = entries
+	}
+	return
+}
+
+func (as *ageKeyStore) setKnownHosts(prefix, id string, entries []knownHostEntry) error {
+	var name = filepath.Join(prefix, id)
+	if len(entries) == 0 {
+		return as.remove(name)
+	}
+	return as.write(name, formatKnownHosts(entries))
+}
+
+// transaction restores changed files if fn fails
+func (as *ageKeyStore) transaction(fn func() error) (err error) {
+	if as.tx != nil {
+		return fn()
+	}
+
+	as.tx = &ageTx{content: make(map[string][]byte)}
+	defer func() {
+		var tx = as.tx
+		as.tx = nil
+		if err == nil {
+			return
+		}
+
+		for i := len(tx.files) - 1; i >= 0; i-- {
+			var name = tx.files[i]
+			var rbErr error
+			if tx.content[name] == nil {
+				rbErr = as.remove(name)
+			} else {
+				rbErr = as.write(name, tx.content[name])
+			}
+			if rbErr != nil {
+				err = fmt.Errorf("%w (rollback of '%s' failed: %s)", err, name, rbErr)
+			}
+		}
+	}()
+
+	return fn()
+}
+
+func (as *ageKeyStore) changed() bool {
+	return as.keysChanged
+}
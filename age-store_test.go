@@ -0,0 +1,89 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"filippo.io/age"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestAgeKeyStore(t *testing.T) *ageKeyStore {
+	identity, err := age.GenerateX25519Identity()
+	assert.Nil(t, err)
+
+	var dir = t.TempDir()
+	var identityFile = filepath.Join(dir, "identity.txt")
+	assert.Nil(t, os.WriteFile(identityFile, []byte(identity.String()+"\n"), 0600))
+
+	as, err := newAgeKeyStore(filepath.Join(dir, "keys"), identityFile)
+	assert.Nil(t, err)
+	return as
+}
+
+func TestAgeKeyStore(t *testing.T) {
+	as := newTestAgeKeyStore(t)
+
+	assert.Nil(t, as.setPassword("ssh/test", "secret"))
+	assert.Nil(t, as.setPublicSSHKey("ssh/test", []byte("public")))
+	assert.Nil(t, as.setMetadata("ssh/test", map[string]string{"owner": "alice"}))
+
+	data, err := os.ReadFile(as.path("ssh/test/password"))
+	assert.Nil(t, err)
+	assert.NotContains(t, string(data), "secret")
+
+	password, err := as.getPassword("ssh/test")
+	assert.Nil(t, err)
+	assert.Equal(t, "secret", password)
+
+	meta, err := as.getMetadata("ssh/test")
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]string{"owner": "alice"}, meta)
+
+	_, err = as.getPrivateSSHKey("ssh/test")
+	assert.Equal(t, ErrNotFound, err)
+
+	ll, err := as.list("ssh")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"test"}, ll)
+
+	err = as.transaction(func() error {
+		assert.Nil(t, as.setPassword("ssh/test", "new"))
+		assert.Nil(t, as.setPrivateSSHKey("ssh/other", []byte("private")))
+		return assert.AnError
+	})
+	assert.ErrorIs(t, err, assert.AnError)
+
+	password, err = as.getPassword("ssh/test")
+	assert.Nil(t, err)
+	assert.Equal(t, "secret", password)
+
+	ll, err = as.list("")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"ssh/test"}, ll)
+
+	assert.Nil(t, as.delPassword("ssh/test"))
+	assert.Nil(t, as.delPublicSSHKey("ssh/test"))
+	_, err = os.Stat(filepath.Join(as.dir, "ssh"))
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestCommandsAgeBackend(t *testing.T) {
+	s, _, run := newTestGc()
+	s.ks = newTestAgeKeyStore(t)
+
+	_, err := run("secret", "generate", "test/key")
+	assert.Nil(t, err)
+
+	out, err := run("secret", "key", "public", "show", "test/key")
+	assert.Nil(t, err)
+	assert.Contains(t, out, "ssh-ed25519 ")
+
+	_, err = run("secret", "delete", "test/key")
+	assert.Nil(t, err)
+
+	ll, err := s.ks.list("")
+	assert.Nil(t, err)
+	assert.Empty(t, ll)
+}
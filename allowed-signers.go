@@ -0,0 +1,435 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/urfave/cli/v2"
+	"golang.org/x/crypto/ssh"
+)
+
+// allowed_signers format - https://man.openbsd.org/ssh-keygen#ALLOWED_SIGNERS
+const (
+	allowedSignersOptCertAuthority = "cert-authority"
+	allowedSignersOptNamespaces    = "namespaces"
+	allowedSignersOptValidAfter    = "valid-after"
+	allowedSignersOptValidBefore   = "valid-before"
+)
+
+var allowedSignersTimeFormats = []string{
+	"20060102150405",
+	"200601021504",
+	"20060102",
+}
+
+type allowedSigner struct {
+	principals    []string
+	certAuthority bool
+	namespaces    []string
+	validAfter    time.Time
+	validBefore   time.Time
+	key           ssh.PublicKey
+	comment       string
+}
+
+type allowedSigners []allowedSigner
+
+// allowedSignerResult is structured result of allowed_signers line built from gopass ssh-key
+type allowedSignerResult struct {
+	Key         string   `json:"key" yaml:"key"`
+	Principals  []string `json:"principals" yaml:"principals"`
+	Fingerprint string   `json:"fingerprint" yaml:"fingerprint"`
+	Line        string   `json:"line" yaml:"line"`
+}
+
+// String returns signer in allowed_signers line format
+func (as allowedSigner) String() string {
+	var options []string
+	if as.certAuthority {
+		options = append(options, allowedSignersOptCertAuthority)
+	}
+	if len(as.namespaces) > 0 {
+		options = append(options, fmt.Sprintf("%s=\"%s\"", allowedSignersOptNamespaces, strings.Join(as.namespaces, ",")))
+	}
+	if !as.validAfter.IsZero() {
+		options = append(options, fmt.Sprintf("%s=\"%s\"", allowedSignersOptValidAfter, formatAllowedSignersTime(as.validAfter)))
+	}
+	if !as.validBefore.IsZero() {
+		options = append(options, fmt.Sprintf("%s=\"%s\"", allowedSignersOptValidBefore, formatAllowedSignersTime(as.validBefore)))
+	}
+
+	var parts = []string{strings.Join(as.principals, ",")}
+	if len(options) > 0 {
+		parts = append(parts, strings.Join(options, ","))
+	}
+
+	parts = append(parts, strings.TrimSpace(string(ssh.MarshalAuthorizedKey(as.key))))
+	if as.comment != "" {
+		parts = append(parts, as.comment)
+	}
+
+	return strings.Join(parts, " ")
+}
+
+func formatAllowedSignersTime(t time.Time) string {
+	return t.UTC().Format(allowedSignersTimeFormats[0]) + "Z"
+}
+
+func parseAllowedSignersTime(in string) (t time.Time, err error) {
+	var loc = time.Local
+	if strings.HasSuffix(in, "Z") || strings.HasSuffix(in, "z") {
+		in = in[:len(in)-1]
+		loc = time.UTC
+	}
+
+	for _, f := range allowedSignersTimeFormats {
+		if len(in) != len(f) {
+			continue
+		}
+		return time.ParseInLocation(f, in, loc)
+	}
+
+	return t, fmt.Errorf("invalid allowed signers time '%s'", in)
+}
+
+// splitAllowedSignersPrincipals splits line to principals field and the rest respecting quotes
+func splitAllowedSignersPrincipals(line string) (principals, rest string) {
+	var quoted bool
+	for i, r := range line {
+		switch {
+		case r == '"':
+			quoted = !quoted
+		case (r == ' ' || r == '\t') && !quoted:
+			return strings.Trim(line[:i], "\""), strings.TrimSpace(line[i:])
+		}
+	}
+	return strings.Trim(line, "\""), ""
+}
+
+func parseAllowedSignerLine(line string) (as allowedSigner, err error) {
+	principals, rest := splitAllowedSignersPrincipals(line)
+	if rest == "" {
+		return as, errors.New("missing public key")
+	}
+
+	as.principals = strings.Split(principals, ",")
+
+	var options []string
+	as.key, as.comment, options, _, err = ssh.ParseAuthorizedKey([]byte(rest))
+	if err != nil {
+		return
+	}
+
+	for _, opt := range options {
+		name, value, _ := strings.Cut(opt, "=")
+		value = strings.Trim(value, "\"")
+
+		switch strings.ToLower(name) {
+		case allowedSignersOptCertAuthority:
+			as.certAuthority = true
+		case allowedSignersOptNamespaces:
+			as.namespaces = strings.Split(value, ",")
+		case allowedSignersOptValidAfter:
+			as.validAfter, err = parseAllowedSignersTime(value)
+		case allowedSignersOptValidBefore:
+			as.validBefore, err = parseAllowedSignersTime(value)
+		default:
+			err = fmt.Errorf("unsupported option '%s'", name)
+		}
+
+		if err != nil {
+			return
+		}
+	}
+
+	return as, nil
+}
+
+func parseAllowedSigners(data []byte) (o allowedSigners, err error) {
+	var lineNum int
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		lineNum++
+
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		as, err := parseAllowedSignerLine(line)
+		if err != nil {
+			return nil, fmt.Errorf("allowed signers line %d: %w", lineNum, err)
+		}
+		o = append(o, as)
+	}
+
+	return o, scanner.Err()
+}
+
+func readAllowedSignersFile(path string) (o allowedSigners, err error) {
+	if path == "" {
+		return nil, errors.New("allowed signers file must be set")
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return
+	}
+
+	return parseAllowedSigners(data)
+}
+
+// matchPrincipal checks identity matches principals patterns
+func (as allowedSigner) matchPrincipal(identity string) bool {
+	return sshPatternListMatch(as.principals, identity)
+}
+
+func (as allowedSigner) matchNamespace(namespace string) bool {
+	if len(as.namespaces) == 0 {
+		return true
+	}
+	return sshPatternListMatch(as.namespaces, namespace)
+}
+
+func (as allowedSigner) validAt(t time.Time) bool {
+	if !as.validAfter.IsZero() && t.Before(as.validAfter) {
+		return false
+	}
+	if !as.validBefore.IsZero() && t.After(as.validBefore) {
+		return false
+	}
+	return true
+}
+
+// matchKey checks key (or certificate signed by authority key) matches signer entry
+func (as allowedSigner) matchKey(key ssh.PublicKey, identity string) bool {
+	cert, isCert := key.(*ssh.Certificate)
+	if !as.certAuthority {
+		return !isCert && bytes.Equal(as.key.Marshal(), key.Marshal())
+	}
+
+	if !isCert || !bytes.Equal(as.key.Marshal(), cert.SignatureKey.Marshal()) {
+		return false
+	}
+
+	for _, p := range cert.ValidPrincipals {
+		if p == identity {
+			return true
+		}
+	}
+	return false
+}
+
+// findPrincipals returns principals allowed to sign with key
+func (ss allowedSigners) findPrincipals(key ssh.PublicKey, t time.Time) (o []string) {
+	for _, as := range ss {
+		if as.certAuthority || !as.validAt(t) {
+			continue
+		}
+		if bytes.Equal(as.key.Marshal(), key.Marshal()) {
+			o = append(o, strings.Join(as.principals, ","))
+		}
+	}
+	return
+}
+
+// findPrincipal checks key is allowed for identity in namespace; if identity is empty
+// any matching principal is returned
+func (ss allowedSigners) findPrincipal(key ssh.PublicKey, identity, namespace string, t time.Time) (string, error) {
+	for _, as := range ss {
+		if identity == "" {
+			if as.certAuthority || !as.matchKey(key, "") {
+				continue
+			}
+		} else if !as.matchPrincipal(identity) || !as.matchKey(key, identity) {
+			continue
+		}
+
+		if !as.matchNamespace(namespace) {
+			continue
+		}
+
+		if !as.validAt(t) {
+			continue
+		}
+
+		if identity == "" {
+			return strings.Join(as.principals, ","), nil
+		}
+		return identity, nil
+	}
+
+	return "", fmt.Errorf("signing key %s is not allowed for '%s' in namespace '%s'",
+		ssh.FingerprintSHA256(key), identity, namespace)
+}
+
+// sshPatternListMatch implements openssh match_pattern_list: comma separated
+// wildcard patterns, prefix '!' negates
+func sshPatternListMatch(patterns []string, s string) bool {
+	var matched bool
+	for _, p := range patterns {
+		p = strings.TrimSpace(p)
+		negated := strings.HasPrefix(p, "!")
+		if negated {
+			p = p[1:]
+		}
+
+		if !sshWildcardMatch(p, s) {
+			continue
+		}
+
+		if negated {
+			return false
+		}
+		matched = true
+	}
+	return matched
+}
+
+// sshWildcardMatch matches s with pattern, which supports '*' and '?'
+func sshWildcardMatch(pattern, s string) bool {
+	for len(pattern) > 0 {
+		switch pattern[0] {
+		case '*':
+			for i := 0; i <= len(s); i++ {
+				if sshWildcardMatch(pattern[1:], s[i:]) {
+					return true
+				}
+			}
+			return false
+		case '?':
+			if len(s) == 0 {
+				return false
+			}
+		default:
+			if len(s) == 0 || pattern[0] != s[0] {
+				return false
+			}
+		}
+		pattern = pattern[1:]
+		s = s[1:]
+	}
+	return len(s) == 0
+}
+
+// parseMetadataTime parses time saved in ssh-key metadata
+func parseMetadataTime(in string) (time.Time, error) {
+	return time.Parse(time.RFC3339, in)
+}
+
+// allowedSignerFromMetadata returns allowed signer for public ssh-key with principals,
+// namespaces and validity period from metadata
+func allowedSignerFromMetadata(pubKeyB []byte, meta map[string]string, validity bool) (as allowedSigner, err error) {
+	var principals = meta[gopassMetaPrincipals]
+	if principals == "" {
+		principals = meta[gopassMetaEmail]
+	}
+	if principals == "" {
+		return as, errors.New("principals or email are not set in metadata")
+	}
+
+	as.principals = strings.Split(principals, ",")
+
+	if ns := meta[gopassMetaNamespaces]; ns != "" {
+		as.namespaces = strings.Split(ns, ",")
+	}
+
+	as.key, _, _, _, err = ssh.ParseAuthorizedKey(pubKeyB)
+	if err != nil {
+		return
+	}
+
+	if !validity {
+		return as, nil
+	}
+
+	if created := meta[gopassMetaCreated]; created != "" {
+		as.validAfter, err = parseMetadataTime(created)
+		if err != nil {
+			return as, fmt.Errorf("invalid '%s' metadata: %w", gopassMetaCreated, err)
+		}
+	}
+
+	if expires := meta[gopassMetaExpires]; expires != "" {
+		as.validBefore, err = parseMetadataTime(expires)
+		if err != nil {
+			return as, fmt.Errorf("invalid '%s' metadata: %w", gopassMetaExpires, err)
+		}
+	}
+
+	return as, nil
+}
+
+// AllowedSignersBuild - generate allowed_signers file from public ssh-keys saved in gopass
+func (s *gc) AllowedSignersBuild(c *cli.Context) error {
+	var store = c.String("store")
+	var prefix = c.String("prefix")
+	var file = c.String("file")
+	var validity = c.Bool("validity")
+
+	s.log().Info("getting ssh-keys list from gopass")
+	ll, err := s.ks.list(store)
+	if err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	var o = make([]allowedSignerResult, 0)
+	for _, p := range ll {
+		if !hasPathPrefix(p, prefix) {
+			continue
+		}
+
+		var key = filepath.Join(store, p)
+		var log = s.log().WithField("gkey", key)
+
+		log.Info("getting public ssh-key from gopass")
+		pubKey, err := s.ks.getPublicSSHKey(key)
+		if err != nil {
+			if err.Error() == ErrNotFound.Error() {
+				log.Warn("public ssh-key is not found, skipping")
+				continue
+			}
+			return err
+		}
+
+		meta, err := s.ks.getMetadata(key)
+		if err != nil && !errors.Is(err, errMetadataNotSupported) {
+			return err
+		}
+
+		as, err := allowedSignerFromMetadata(pubKey, meta, validity)
+		if err != nil {
+			log.Warnf("skipping: %s", err)
+			continue
+		}
+
+		as.comment = getSSHKeyComment(key)
+		buf.WriteString(as.String())
+		buf.WriteByte('\n')
+		o = append(o, allowedSignerResult{
+			Key:         key,
+			Principals:  as.principals,
+			Fingerprint: ssh.FingerprintSHA256(as.key),
+			Line:        as.String(),
+		})
+	}
+
+	if file == "" || file == "-" {
+		return s.printResult(o, buf.String())
+	}
+
+	s.log().Infof("writing allowed signers to '%s'", file)
+	err = os.WriteFile(file, buf.Bytes(), 0644)
+	if err != nil {
+		return err
+	}
+
+	return s.printResult(o, "")
+}
@@ -1,6 +1,9 @@
 package main
 
-import "github.com/urfave/cli/v2"
+import (
+	"github.com/sethvargo/go-password/password"
+	"github.com/urfave/cli/v2"
+)
 
 var appCopyFlag = &cli.BoolFlag{
 	Name:    "clipboard",
@@ -10,11 +13,18 @@ var appCopyFlag = &cli.BoolFlag{
 }
 
 var appPasswordFlags = []cli.Flag{
+	&cli.StringFlag{
+		Name:        "mode",
+		Value:       passwordModeRandom,
+		DefaultText: passwordModeRandom,
+		Aliases:     []string{"m"},
+		Usage:       "Password mode (random, words, pin)",
+	},
 	&cli.IntFlag{
 		Name:    "length",
 		Value:   32,
 		Aliases: []string{"l"},
-		Usage:   "Password length",
+		Usage:   "Password length (random and pin modes)",
 	},
 	&cli.BoolFlag{
 		Name:    "symbols",
@@ -22,6 +32,50 @@ var appPasswordFlags = []cli.Flag{
 		Aliases: []string{"s"},
 		Usage:   "Add symbols to password",
 	},
+	&cli.IntFlag{
+		Name:        "digits",
+		Value:       -1,
+		DefaultText: "1/4 of length",
+		Usage:       "Digits count (random mode)",
+	},
+	&cli.IntFlag{
+		Name:        "symbols-count",
+		Value:       -1,
+		DefaultText: "1/4 of length",
+		Usage:       "Symbols count (random mode)",
+	},
+	&cli.StringFlag{
+		Name:  "symbol-set",
+		Value: password.Symbols,
+		Usage: "Allowed symbols (random mode)",
+	},
+	&cli.IntFlag{
+		Name:    "words",
+		Value:   6,
+		Aliases: []string{"w"},
+		Usage:   "Words count (words mode)",
+	},
+	&cli.StringFlag{
+		Name:  "separator",
+		Value: "-",
+		Usage: "Words separator (words mode)",
+	},
+}
+
+var appPasswordInputFlags = []cli.Flag{
+	&cli.StringFlag{
+		Name:        "input",
+		Value:       passwordInputAuto,
+		DefaultText: passwordInputAuto,
+		Aliases:     []string{"i"},
+		Usage:       "Password input (auto, pinentry, terminal, stdin), auto reads stdin if it is not a terminal",
+	},
+	&cli.StringFlag{
+		Name:        "pinentry",
+		Value:       "pinentry",
+		DefaultText: "pinentry",
+		Usage:       "Pinentry program, terminal prompt is used if it is not found",
+	},
 }
 
 var appSSHKeygenFlags = []cli.Flag{
@@ -43,4 +97,38 @@ var appSSHKeygenFlags = []cli.Flag{
 		Aliases: []string{"bit", "b"},
 		Usage:   "Ssh key bits",
 	},
+	&cli.StringFlag{
+		Name:  "expires",
+		Value: "",
+		Usage: "Ssh key expiry saved in metadata, duration (90d, 2w) or date (2006-01-02)",
+	},
+}
+
+var appKnownHostsPrefixFlag = &cli.StringFlag{
+	Name:        "prefix",
+	Value:       "known-hosts",
+	DefaultText: "known-hosts",
+	Usage:       "gopass path prefix where known_hosts entries are saved",
+}
+
+var appKnownHostsFileFlag = &cli.StringFlag{
+	Name:        "file",
+	Value:       defaultKnownHostsFile,
+	DefaultText: defaultKnownHostsFile,
+	Aliases:     []string{"f"},
+	Usage:       "Local known_hosts file path (\"-\" for stdin/stdout)",
+}
+
+var appKnownHostsHashFlag = &cli.BoolFlag{
+	Name:    "hash",
+	Value:   false,
+	Aliases: []string{"H"},
+	Usage:   "Hash hostnames",
+}
+
+var appNamespaceFlag = &cli.StringFlag{
+	Name:    "namespace",
+	Value:   "file",
+	Aliases: []string{"n"},
+	Usage:   "Signature namespace (e.g. git, file)",
 }
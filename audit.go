@@ -0,0 +1,196 @@
+package main
+
+import (
+	"crypto/rsa"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/nbutton23/zxcvbn-go"
+	"github.com/urfave/cli/v2"
+	"golang.org/x/crypto/ssh"
+)
+
+const auditIssueNotEncrypted = "private ssh-key is not encrypted"
+
+// private ssh-key PEM block types, other types are legacy PEM formats
+const (
+	pemTypeOpenSSH = "OPENSSH PRIVATE KEY"
+	pemTypeDSA     = "DSA PRIVATE KEY"
+)
+
+// auditResult is structured result of ssh-key audit
+type auditResult struct {
+	Key           string   `json:"key" yaml:"key"`
+	PasswordScore int      `json:"password_score" yaml:"password_score"`
+	KeyType       string   `json:"key_type,omitempty" yaml:"key_type,omitempty"`
+	Issues        []string `json:"issues" yaml:"issues"`
+}
+
+// auditPassword checks passphrase strength with zxcvbn, ssh-key path segments are used as user inputs
+func auditPassword(key, password string, minScore int) (score int, issues []string) {
+	if password == "" {
+		return -1, []string{"passphrase is missing"}
+	}
+
+	var res = zxcvbn.PasswordStrength(password, strings.Split(key, "/"))
+	if res.Score < minScore {
+		issues = append(issues, fmt.Sprintf("weak passphrase (score %d/4, cracked in %s)", res.Score, res.CrackTimeDisplay))
+	}
+	return res.Score, issues
+}
+
+// auditPrivateSSHKey checks private ssh-key encryption, format, type and size
+func auditPrivateSSHKey(data []byte, password string, minRSABits int) (keyType string, issues []string) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return "", []string{"private ssh-key is not PEM encoded"}
+	}
+
+	if block.Type != pemTypeOpenSSH {
+		issues = append(issues, fmt.Sprintf("legacy PEM format (%s)", block.Type))
+	}
+
+	var pubKey ssh.PublicKey
+	signer, err := ssh.ParsePrivateKey(data)
+	var missingErr *ssh.PassphraseMissingError
+	switch {
+	case err == nil:
+		pubKey = signer.PublicKey()
+		issues = append(issues, auditIssueNotEncrypted)
+	case errors.As(err, &missingErr):
+		if password != "" {
+			if _, err := ssh.ParsePrivateKeyWithPassphrase(data, []byte(password)); err != nil {
+				issues = append(issues, "passphrase does not decrypt private ssh-key")
+			}
+		}
+		// legacy encrypted PEM keys include public key only in decrypted key
+		pubKey, _ = getPrivateSSHKeyPublicKey(data, password)
+	case block.Type == pemTypeDSA:
+		// DSA keys are not supported by x/crypto/ssh
+		return ssh.KeyAlgoDSA, append(issues, "DSA ssh-key")
+	default:
+		return "", append(issues, fmt.Sprintf("cannot parse private ssh-key: %s", err))
+	}
+
+	// legacy encrypted PEM key without passphrase
+	if pubKey == nil {
+		return "", issues
+	}
+
+	keyType = pubKey.Type()
+	switch keyType {
+	case ssh.KeyAlgoDSA:
+		issues = append(issues, "DSA ssh-key")
+	case ssh.KeyAlgoRSA:
+		if cpk, ok := pubKey.(ssh.CryptoPublicKey); ok {
+			if rsaKey, ok := cpk.CryptoPublicKey().(*rsa.PublicKey); ok && rsaKey.N.BitLen() < minRSABits {
+				issues = append(issues, fmt.Sprintf("weak RSA ssh-key size (%d < %d bits)", rsaKey.N.BitLen(), minRSABits))
+			}
+		}
+	}
+	return keyType, issues
+}
+
+// Audit - check passphrases and private ssh-keys of stored ssh-keys
+func (s *gc) Audit(c *cli.Context) (err error) {
+	var store = c.String("store")
+	var minScore = c.Int("min-score")
+	var minRSABits = c.Int("min-rsa-bits")
+
+	var paths = c.Args().Slice()
+	if len(paths) == 0 {
+		s.log().Info("getting ssh-keys list from gopass")
+		paths, err = s.ks.list(store)
+		if err != nil {
+			return err
+		}
+	}
+
+	var o = make([]auditResult, 0, len(paths))
+	var passwords = make(map[string][]int)
+	for _, p := range paths {
+		var key = filepath.Join(store, p)
+		var log = s.log().WithField("gkey", key)
+		var res = auditResult{Key: key, Issues: []string{}}
+
+		log.Info("getting password from gopass")
+		password, err := s.ks.getPassword(key)
+		if err != nil && err.Error() != ErrNotFound.Error() {
+			return err
+		}
+
+		log.Info("getting private ssh-key from gopass")
+		privKey, err := s.ks.getPrivateSSHKey(key)
+		if err != nil && err.Error() != ErrNotFound.Error() {
+			return err
+		}
+
+		if privKey == nil {
+			res.Issues = append(res.Issues, "private ssh-key is missing")
+		} else {
+			var issues []string
+			res.KeyType, issues = auditPrivateSSHKey(privKey, password, minRSABits)
+			res.Issues = append(res.Issues, issues...)
+		}
+
+		// unencrypted private ssh-key does not need passphrase
+		var issues []string
+		res.PasswordScore, issues = auditPassword(key, password, minScore)
+		if password != "" || !hasString(res.Issues, auditIssueNotEncrypted) {
+			res.Issues = append(res.Issues, issues...)
+		}
+
+		if password != "" {
+			var h = getHash(password)
+			passwords[h] = append(passwords[h], len(o))
+		}
+		o = append(o, res)
+	}
+
+	for _, idx := range passwords {
+		if len(idx) < 2 {
+			continue
+		}
+
+		for _, i := range idx {
+			var others []string
+			for _, j := range idx {
+				if j != i {
+					others = append(others, o[j].Key)
+				}
+			}
+			sort.Strings(others)
+			o[i].Issues = append(o[i].Issues, fmt.Sprintf("passphrase is shared with %s", strings.Join(others, ", ")))
+		}
+	}
+
+	var text strings.Builder
+	var failed int
+	for _, res := range o {
+		if len(res.Issues) == 0 {
+			continue
+		}
+
+		failed++
+		fmt.Fprintf(&text, "%s:\n", res.Key)
+		for _, issue := range res.Issues {
+			fmt.Fprintf(&text, "  - %s\n", issue)
+		}
+	}
+
+	err = s.printResult(o, text.String())
+	if err != nil {
+		return err
+	}
+
+	if failed > 0 {
+		return newAppError(errCodeAuditFailed, fmt.Errorf("%d of %d ssh-keys have issues", failed, len(o)))
+	}
+
+	s.log().Infof("%d ssh-keys have no issues", len(o))
+	return nil
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/ssh"
+)
+
+func TestCommandsAudit(t *testing.T) {
+	_, ms, run := newTestGc()
+
+	_, err := run("secret", "generate", "--mode", "words", "--words", "8", "test/strong")
+	assert.Nil(t, err)
+
+	privKey, pubKey, err := sshKeygen(sshKeyTypeRsa, "", 2048)
+	assert.Nil(t, err)
+	assert.Nil(t, ms.setPrivateSSHKey("ssh-keys/test/rsa", privKey))
+	assert.Nil(t, ms.setPublicSSHKey("ssh-keys/test/rsa", pubKey))
+
+	out, err := run("--output", "json", "secret", "audit", "test/strong")
+	assert.Nil(t, err)
+
+	var res []auditResult
+	assert.Nil(t, json.Unmarshal([]byte(out), &res))
+	assert.Len(t, res, 1)
+	assert.Equal(t, 4, res[0].PasswordScore)
+	assert.Empty(t, res[0].Issues)
+
+	assert.Nil(t, ms.setPassword("ssh-keys/test/rsa", ms.passwords["ssh-keys/test/strong"]))
+	out, err = run("--output", "json", "secret", "audit")
+	assert.Equal(t, errCodeAuditFailed, getAppError(err).Code)
+
+	assert.Nil(t, json.Unmarshal([]byte(out), &res))
+	assert.Len(t, res, 2)
+	assert.Equal(t, []string{"passphrase is shared with ssh-keys/test/rsa"}, res[1].Issues)
+	assert.Equal(t, []string{
+		"legacy PEM format (RSA PRIVATE KEY)",
+		auditIssueNotEncrypted,
+		"weak RSA ssh-key size (2048 < 3072 bits)",
+		"passphrase is shared with ssh-keys/test/strong",
+	}, res[0].Issues)
+}
+
+func TestCommandsAuditEncryptedRSA(t *testing.T) {
+	_, _, run := newTestGc()
+
+	_, err := run("secret", "generate", "--type", "rsa", "--bits", "2048", "test/rsa")
+	assert.Nil(t, err)
+
+	out, err := run("--output", "json", "secret", "audit")
+	assert.Equal(t, errCodeAuditFailed, getAppError(err).Code)
+
+	var res []auditResult
+	assert.Nil(t, json.Unmarshal([]byte(out), &res))
+	assert.Len(t, res, 1)
+	assert.Equal(t, ssh.KeyAlgoRSA, res[0].KeyType)
+	assert.Contains(t, res[0].Issues, "weak RSA ssh-key size (2048 < 3072 bits)")
+	assert.NotContains(t, res[0].Issues, auditIssueNotEncrypted)
+}
+
+func TestAuditPassword(t *testing.T) {
+	score, issues := auditPassword("ssh-keys/test", "password1", 3)
+	assert.Equal(t, 0, score)
+	assert.Len(t, issues, 1)
+
+	score, issues = auditPassword("ssh-keys/test", "", 3)
+	assert.Equal(t, -1, score)
+	assert.Equal(t, []string{"passphrase is missing"}, issues)
+}
@@ -1,9 +1,14 @@
 $fn = $($MyInvocation.MyCommand.Name)
 $name = $fn -replace "(.*)\.ps1$", '$1'
 Register-ArgumentCompleter -Native -CommandName $name -ScriptBlock {
-     param($commandName, $wordToComplete, $cursorPosition)
-     $other = "$wordToComplete --generate-bash-completion"
+     param($wordToComplete, $commandAst, $cursorPosition)
+     # current word is always passed (also empty) to complete path segments and flag values
+     $line = $commandAst.Extent.Text
+     if ($wordToComplete -eq '') {
+         $line = "$line ''"
+     }
+     $other = "$line --generate-bash-completion"
          Invoke-Expression $other | ForEach-Object {
             [System.Management.Automation.CompletionResult]::new($_, $_, 'ParameterValue', $_)
          }
- }
\ No newline at end of file
+ }
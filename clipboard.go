@@ -6,6 +6,11 @@ import (
 	"github.com/gopasspw/gopass/pkg/clipboard"
 )
 
+// clipboardCopier copies secret content to clipboard and clears it after timeout
+type clipboardCopier interface {
+	copy(name string, content []byte, timeout int) error
+}
+
 type clp struct {
 	ctx context.Context
 }
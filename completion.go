@@ -0,0 +1,232 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/urfave/cli/v2"
+)
+
+const (
+	completionFlag     = "--generate-bash-completion"
+	completionCacheTTL = 30 * time.Second
+)
+
+// completionFlagValues returns values of flags which can be completed
+func (s *gc) completionFlagValues(name string) []string {
+	switch name {
+	case "type":
+		return []string{sshKeyTypeEd25519, sshKeyTypeRsa}
+	case "layout", "to":
+		return gopassLayouts
+	case "output":
+		return appOutputs
+	case "log-format":
+		return []string{appLogFormatText, appLogFormatJSON}
+	case "backend":
+		return appBackends
+	case "input":
+		return passwordInputs
+	case "mode":
+		return passwordModes
+	case "store":
+		return s.completionStores()
+	}
+	return nil
+}
+
+// completionStores returns top level directories of ssh-key paths and configured mounts
+func (s *gc) completionStores() (o []string) {
+	var m = make(map[string]bool)
+	keys, _ := s.listCached()
+	for _, k := range keys {
+		if dir, _, found := strings.Cut(k, "/"); found {
+			m[dir] = true
+		}
+	}
+
+	for prefix := range s.cfg.Mounts {
+		m[strings.Trim(prefix, "/")] = true
+	}
+
+	for k := range m {
+		o = append(o, k)
+	}
+	sort.Strings(o)
+	return
+}
+
+func completionCacheFile() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, appName, "completion.json")
+}
+
+// invalidateCompletionCache removes cached listing when secret is added or removed
+func invalidateCompletionCache() {
+	if file := completionCacheFile(); file != "" {
+		_ = os.Remove(file)
+	}
+}
+
+// After - invalidate cached listing once if command added or removed ssh-keys
+func (s *gc) After(_ *cli.Context) error {
+	if s.ks != nil && s.ks.changed() {
+		invalidateCompletionCache()
+	}
+	return nil
+}
+
+// completionCache is cached listing of storage backend
+type completionCache struct {
+	Backend string   `json:"backend"`
+	Keys    []string `json:"keys"`
+}
+
+// listCached returns all ssh-key paths, listing is cached for completionCacheTTL
+func (s *gc) listCached() (keys []string, err error) {
+	if s.ks == nil {
+		return nil, ErrNotFound
+	}
+
+	var backend = getKeyStoreID(s.ks)
+	var file = completionCacheFile()
+	if file != "" {
+		if info, err := os.Stat(file); err == nil && time.Since(info.ModTime()) < completionCacheTTL {
+			var cache completionCache
+			data, err := os.ReadFile(file)
+			if err == nil && json.Unmarshal(data, &cache) == nil && cache.Backend == backend {
+				return cache.Keys, nil
+			}
+		}
+	}
+
+	keys, err = s.ks.list("")
+	if err != nil || file == "" {
+		return
+	}
+
+	// cache is optional, errors are ignored
+	data, _ := json.Marshal(completionCache{Backend: backend, Keys: keys})
+	if os.MkdirAll(filepath.Dir(file), 0700) == nil {
+		_ = os.WriteFile(file, data, 0600)
+	}
+	return keys, nil
+}
+
+// completePathSegment returns paths matching prefix completed up to next path segment
+func completePathSegment(paths []string, prefix string) (o []string) {
+	var dir string
+	if i := strings.LastIndex(prefix, "/"); i >= 0 {
+		dir = prefix[:i+1]
+	}
+
+	var m = make(map[string]bool)
+	for _, p := range paths {
+		if !strings.HasPrefix(p, prefix) {
+			continue
+		}
+
+		var c = p
+		if i := strings.Index(p[len(dir):], "/"); i >= 0 {
+			c = p[:len(dir)+i+1]
+		}
+
+		if !m[c] {
+			m[c] = true
+			o = append(o, c)
+		}
+	}
+
+	sort.Strings(o)
+	return
+}
+
+// completeFlagValue prints values of flag completed in command line (`--flag <cur> --generate-bash-completion`),
+// returns false if last argument is not flag value
+func (s *gc) completeFlagValue(app *cli.App, args []string) bool {
+	if len(args) < 3 || args[len(args)-1] != completionFlag {
+		return false
+	}
+
+	var prev = args[len(args)-3]
+	var cur = args[len(args)-2]
+	if !strings.HasPrefix(prev, "-") || strings.Contains(prev, "=") {
+		return false
+	}
+
+	// storage backend is opened with flag defaults, command line is not parsed
+	if s.ks == nil {
+		s.ks, _ = s.openKeyStore(context.Background(), s.getDefaultFlagValue(app))
+	}
+
+	var name = strings.TrimLeft(prev, "-")
+	for _, ff := range append(getCommandsFlags(app.Commands), app.Flags) {
+		for _, f := range ff {
+			df, ok := f.(cli.DocGenerationFlag)
+			if !ok || !df.TakesValue() || !hasString(f.Names(), name) {
+				continue
+			}
+
+			for _, v := range s.completionFlagValues(f.Names()[0]) {
+				if strings.HasPrefix(v, cur) {
+					fmt.Println(v)
+				}
+			}
+			return true
+		}
+	}
+
+	return false
+}
+
+// PathAutocomplete - complete ssh-key path segment or command flags
+func (s *gc) PathAutocomplete(c *cli.Context) {
+	var cur = c.Args().Get(c.NArg() - 1)
+	if strings.HasPrefix(cur, "-") {
+		cli.DefaultCompleteWithFlags(c.Command)(c)
+		return
+	}
+
+	if c.NArg() > 1 {
+		return
+	}
+
+	if s.openContextKeyStore(c) != nil {
+		return
+	}
+
+	keys, err := s.listCached()
+	if err != nil {
+		return
+	}
+
+	var store = s.getFlagValue(c, "store") + "/"
+	var paths []string
+	for _, k := range keys {
+		if strings.HasPrefix(k, store) {
+			paths = append(paths, strings.TrimPrefix(k, store))
+		}
+	}
+
+	for _, p := range completePathSegment(paths, cur) {
+		fmt.Println(p)
+	}
+}
+
+func hasString(ll []string, s string) bool {
+	for _, l := range ll {
+		if l == s {
+			return true
+		}
+	}
+	return false
+}
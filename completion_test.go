@@ -0,0 +1,50 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCompletePathSegment(t *testing.T) {
+	var paths = []string{"prod/web/a", "prod/web/b", "prod/db", "dev", "dev/nested/key"}
+
+	assert.Equal(t, []string{"dev", "dev/", "prod/"}, completePathSegment(paths, ""))
+	assert.Equal(t, []string{"prod/db", "prod/web/"}, completePathSegment(paths, "prod/"))
+	assert.Equal(t, []string{"prod/web/"}, completePathSegment(paths, "prod/w"))
+	assert.Equal(t, []string{"prod/web/a", "prod/web/b"}, completePathSegment(paths, "prod/web/"))
+	assert.Empty(t, completePathSegment(paths, "stage"))
+}
+
+func TestKeyStoreIDGopassConfig(t *testing.T) {
+	gs, _ := newTestGopassStorage()
+
+	t.Setenv("GOPASS_HOMEDIR", "/home/a")
+	var a = getKeyStoreID(gs)
+	t.Setenv("GOPASS_HOMEDIR", "/home/b")
+	assert.NotEqual(t, a, getKeyStoreID(gs))
+
+	var b = getKeyStoreID(gs)
+	t.Setenv("GOPASS_CONFIG", "/etc/gopass.yml")
+	assert.NotEqual(t, b, getKeyStoreID(gs))
+}
+
+func TestCommandsCompletionCacheInvalidated(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	_, _, run := newTestGc()
+
+	var file = completionCacheFile()
+	assert.Nil(t, os.MkdirAll(filepath.Dir(file), 0700))
+	assert.Nil(t, os.WriteFile(file, []byte("{}"), 0600))
+
+	// listing is not changed by read-only command
+	_, err := run("expiring")
+	assert.Nil(t, err)
+	assert.FileExists(t, file)
+
+	_, err = run("secret", "generate", "test/key")
+	assert.Nil(t, err)
+	assert.NoFileExists(t, file)
+}
@@ -0,0 +1,301 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/urfave/cli/v2"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	appConfigEnv             = "GOPASS_SSH_ADD_CONFIG"
+	appConfigFilename        = "config.yaml"
+	appConfigProjectFilename = ".gopass-ssh-add.yaml"
+	appConfigEnvPrefix       = "GOPASS_SSH_ADD_"
+)
+
+// appConfig is gopass-ssh-add config file, `defaults` and `prefixes` values are flag values
+// (`prefixes` patterns are matched with ssh-key path and support '*' and '?'):
+//
+//	defaults:
+//	  store: ssh-keys
+//	  type: ed25519
+//	  length: 48
+//	prefixes:
+//	  prod/*:
+//	    lifetime: 3600
+//	    confirm: true
+//	mounts:
+//	  team/ssh:
+//	    layout: split
+//	    password:
+//	      name: passphrase
+//	    private-key:
+//	      name: id_ed25519
+//	      encoding: raw
+//	    public-key:
+//	      name: id_ed25519.pub
+//	      encoding: raw
+type appConfig struct {
+	files    []string
+	Defaults map[string]any            `json:"defaults,omitempty" yaml:"defaults,omitempty"`
+	Prefixes map[string]map[string]any `json:"prefixes,omitempty" yaml:"prefixes,omitempty"`
+	Mounts   map[string]gopassMount    `json:"mounts,omitempty" yaml:"mounts,omitempty"`
+}
+
+// appConfigPath returns path of file in app config directory (XDG config directory on linux),
+// path in home directory starts with `~`
+func appConfigPath(name string) string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+
+	var p = filepath.Join(dir, appName, name)
+	if home, err := os.UserHomeDir(); err == nil && strings.HasPrefix(p, home+string(filepath.Separator)) {
+		return "~" + p[len(home):]
+	}
+	return p
+}
+
+// defaultConfigFile returns config file path from env or XDG config directory
+func defaultConfigFile() string {
+	if p := os.Getenv(appConfigEnv); p != "" {
+		return p
+	}
+	return expandHomeDir(appConfigPath(appConfigFilename))
+}
+
+// projectConfigFile returns nearest project config file in current directory or its parents
+func projectConfigFile() string {
+	dir, err := os.Getwd()
+	if err != nil {
+		return ""
+	}
+
+	for {
+		var p = filepath.Join(dir, appConfigProjectFilename)
+		if _, err := os.Stat(p); err == nil {
+			return p
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
+
+// appConfigEnvName returns environment variable name for flag
+func appConfigEnvName(flag string) string {
+	return appConfigEnvPrefix + strings.ToUpper(strings.ReplaceAll(flag, "-", "_"))
+}
+
+// loadConfig reads and merges config files, later file overrides earlier one, missing file is skipped
+func loadConfig(paths ...string) (c appConfig, err error) {
+	c = appConfig{
+		Defaults: make(map[string]any),
+		Prefixes: make(map[string]map[string]any),
+		Mounts:   make(map[string]gopassMount),
+	}
+
+	for _, path := range paths {
+		if path == "" {
+			continue
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				continue
+			}
+			return c, err
+		}
+
+		var fc appConfig
+		err = yaml.Unmarshal(data, &fc)
+		if err != nil {
+			return c, fmt.Errorf("cannot parse config file '%s': %w", path, err)
+		}
+
+		err = checkGopassMounts(fc.Mounts)
+		if err != nil {
+			return c, fmt.Errorf("invalid config file '%s': %w", path, err)
+		}
+
+		c.merge(fc)
+		c.files = append(c.files, path)
+	}
+
+	return c, nil
+}
+
+func (c *appConfig) merge(o appConfig) {
+	for k, v := range o.Defaults {
+		c.Defaults[k] = v
+	}
+
+	for p, values := range o.Prefixes {
+		if c.Prefixes[p] == nil {
+			c.Prefixes[p] = make(map[string]any)
+		}
+		for k, v := range values {
+			c.Prefixes[p][k] = v
+		}
+	}
+
+	for p, m := range o.Mounts {
+		c.Mounts[p] = m
+	}
+}
+
+// configValue converts yaml value to flag values
+func configValue(v any) []string {
+	if l, ok := v.([]any); ok {
+		var o []string
+		for _, i := range l {
+			o = append(o, fmt.Sprint(i))
+		}
+		return o
+	}
+	return []string{fmt.Sprint(v)}
+}
+
+// values returns flag values: config defaults, overridden by prefix patterns matching ssh-key path
+// (longer pattern wins), overridden by environment variables (flags override all of them)
+func (c appConfig) values(names []string, path string) map[string][]string {
+	var o = make(map[string][]string)
+	for _, name := range names {
+		if v, ok := c.Defaults[name]; ok {
+			o[name] = configValue(v)
+		}
+	}
+
+	if path != "" {
+		var patterns []string
+		for p := range c.Prefixes {
+			if sshWildcardMatch(p, path) {
+				patterns = append(patterns, p)
+			}
+		}
+		sort.Slice(patterns, func(i, j int) bool {
+			return len(patterns[i]) < len(patterns[j])
+		})
+
+		for _, p := range patterns {
+			for _, name := range names {
+				if v, ok := c.Prefixes[p][name]; ok {
+					o[name] = configValue(v)
+				}
+			}
+		}
+	}
+
+	for _, name := range names {
+		if v, ok := os.LookupEnv(appConfigEnvName(name)); ok {
+			o[name] = []string{v}
+		}
+	}
+
+	return o
+}
+
+// getFlagNames returns unique sorted flag names
+func getFlagNames(flags ...[]cli.Flag) (o []string) {
+	var m = make(map[string]bool)
+	for _, ff := range flags {
+		for _, f := range ff {
+			var name = f.Names()[0]
+			if name == "help" || name == "version" || m[name] {
+				continue
+			}
+			m[name] = true
+			o = append(o, name)
+		}
+	}
+	sort.Strings(o)
+	return
+}
+
+// getContextFlagNames returns names of flags available in context and its parents
+func getContextFlagNames(c *cli.Context) []string {
+	var flags [][]cli.Flag
+	for _, ctx := range c.Lineage() {
+		if ctx.Command != nil {
+			flags = append(flags, ctx.Command.Flags)
+		}
+	}
+	return getFlagNames(flags...)
+}
+
+func getCommandsFlags(commands []*cli.Command) (o [][]cli.Flag) {
+	for _, cmd := range commands {
+		o = append(o, cmd.Flags)
+		o = append(o, getCommandsFlags(cmd.Subcommands)...)
+	}
+	return
+}
+
+// applyConfig sets flags which are not set in command line from config file and environment
+func (s *gc) applyConfig(c *cli.Context, path string) error {
+	var names = getContextFlagNames(c)
+	for name, values := range s.cfg.values(names, path) {
+		if c.IsSet(name) {
+			continue
+		}
+
+		for _, v := range values {
+			err := c.Set(name, v)
+			if err != nil {
+				return fmt.Errorf("invalid config value for '%s': %w", name, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// ConfigShow - print effective configuration merged from config files and environment
+func (s *gc) ConfigShow(c *cli.Context) error {
+	var path = c.Args().Get(0)
+	var names = getFlagNames(append(getCommandsFlags(c.App.Commands), c.App.Flags)...)
+
+	var o = struct {
+		Files     []string `json:"files,omitempty" yaml:"files,omitempty"`
+		appConfig `yaml:",inline"`
+	}{
+		Files: s.cfg.files,
+		appConfig: appConfig{
+			Defaults: make(map[string]any),
+			Prefixes: s.cfg.Prefixes,
+			Mounts:   s.cfg.Mounts,
+		},
+	}
+
+	for name, values := range s.cfg.values(names, path) {
+		if len(values) == 1 {
+			o.Defaults[name] = values[0]
+			continue
+		}
+		o.Defaults[name] = values
+	}
+
+	// prefix overrides are already applied to defaults
+	if path != "" {
+		o.Prefixes = nil
+	}
+
+	if s.structured() {
+		return s.printResult(o, "")
+	}
+
+	// text output is yaml as well
+	return encodeOutput(s.stdout, appOutputYAML, o)
+}
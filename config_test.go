@@ -0,0 +1,69 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConfigValues(t *testing.T) {
+	dir := t.TempDir()
+	userFile := filepath.Join(dir, "config.yaml")
+	projectFile := filepath.Join(dir, appConfigProjectFilename)
+
+	assert.Nil(t, os.WriteFile(userFile, []byte(`
+defaults:
+  length: 40
+  type: rsa
+prefixes:
+  prod/*:
+    lifetime: 3600
+    length: 30
+  prod/db/*:
+    lifetime: 600
+`), 0600))
+	assert.Nil(t, os.WriteFile(projectFile, []byte(`
+defaults:
+  type: ed25519
+  key: [a, b]
+`), 0600))
+
+	cfg, err := loadConfig(userFile, projectFile, filepath.Join(dir, "missing.yaml"))
+	assert.Nil(t, err)
+	assert.Equal(t, []string{userFile, projectFile}, cfg.files)
+
+	t.Setenv(appConfigEnvName("length"), "20")
+	names := []string{"length", "type", "key", "lifetime"}
+
+	values := cfg.values(names, "")
+	assert.Equal(t, []string{"20"}, values["length"])
+	assert.Equal(t, []string{"ed25519"}, values["type"])
+	assert.Equal(t, []string{"a", "b"}, values["key"])
+	assert.NotContains(t, values, "lifetime")
+
+	assert.Equal(t, []string{"3600"}, cfg.values(names, "prod/web")["lifetime"])
+	assert.Equal(t, []string{"600"}, cfg.values(names, "prod/db/main")["lifetime"])
+	assert.NotContains(t, cfg.values(names, "dev/web"), "lifetime")
+
+	// environment variables override prefix patterns
+	assert.Equal(t, []string{"20"}, cfg.values(names, "prod/web")["length"])
+	os.Unsetenv(appConfigEnvName("length"))
+	assert.Equal(t, []string{"30"}, cfg.values(names, "prod/web")["length"])
+	assert.Equal(t, []string{"40"}, cfg.values(names, "dev/web")["length"])
+}
+
+func TestAppConfigPath(t *testing.T) {
+	var home = t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv(appConfigEnv, "")
+
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(home, "xdg"))
+	assert.Equal(t, "~/xdg/"+appName+"/"+appPolicyFilename, appConfigPath(appPolicyFilename))
+	assert.Equal(t, filepath.Join(home, "xdg", appName, appConfigFilename), defaultConfigFile())
+
+	var dir = t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", dir)
+	assert.Equal(t, filepath.Join(dir, appName, appPolicyFilename), appConfigPath(appPolicyFilename))
+}
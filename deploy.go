@@ -0,0 +1,283 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"os/user"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/urfave/cli/v2"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+// remote commands used to read and atomically replace authorized_keys file,
+// only missing file is read as empty, any other read failure is an error
+const (
+	deployReadCmd  = "test -d ~ && { test ! -e ~/.ssh/authorized_keys || cat ~/.ssh/authorized_keys; }"
+	deployWriteCmd = "umask 077 && mkdir -p ~/.ssh && " +
+		"cat > ~/.ssh/authorized_keys.gssh && mv -f ~/.ssh/authorized_keys.gssh ~/.ssh/authorized_keys"
+)
+
+// deployDialTimeout limits time to establish connection to remote host
+const deployDialTimeout = 30 * time.Second
+
+// parseDeployTarget parses `[user@]host[:port]`
+func parseDeployTarget(target string, port int) (username, addr string, err error) {
+	username, host, found := strings.Cut(target, "@")
+	if !found {
+		host = username
+		username = ""
+	}
+
+	if username == "" {
+		u, err := user.Current()
+		if err != nil {
+			return "", "", err
+		}
+		username = u.Username
+	}
+
+	if host == "" {
+		return "", "", fmt.Errorf("invalid deploy target '%s'", target)
+	}
+
+	if h, p, err := net.SplitHostPort(host); err == nil {
+		return username, net.JoinHostPort(h, p), nil
+	}
+
+	return username, net.JoinHostPort(strings.Trim(host, "[]"), strconv.Itoa(port)), nil
+}
+
+// authorizedKeysLines splits authorized_keys data to lines without line length limit
+func authorizedKeysLines(data []byte) [][]byte {
+	data = bytes.TrimSuffix(data, []byte("\n"))
+	if len(data) == 0 {
+		return nil
+	}
+	return bytes.Split(data, []byte("\n"))
+}
+
+// authorizedKeysContains checks authorized_keys data contains public key
+func authorizedKeysContains(data []byte, pubKey ssh.PublicKey) bool {
+	for _, line := range authorizedKeysLines(data) {
+		key, _, _, _, err := ssh.ParseAuthorizedKey(line)
+		if err == nil && bytes.Equal(key.Marshal(), pubKey.Marshal()) {
+			return true
+		}
+	}
+	return false
+}
+
+// authorizedKeysAdd appends public key line to authorized_keys data if key is missing
+func authorizedKeysAdd(data []byte, pubKey ssh.PublicKey, line []byte) (o []byte, changed bool) {
+	if authorizedKeysContains(data, pubKey) {
+		return data, false
+	}
+
+	o = append(o, data...)
+	if len(o) > 0 && o[len(o)-1] != '\n' {
+		o = append(o, '\n')
+	}
+	o = append(o, bytes.TrimSpace(line)...)
+	return append(o, '\n'), true
+}
+
+// authorizedKeysRemove removes all lines with public key from authorized_keys data
+func authorizedKeysRemove(data []byte, pubKey ssh.PublicKey) (o []byte, changed bool) {
+	for _, line := range authorizedKeysLines(data) {
+		key, _, _, _, err := ssh.ParseAuthorizedKey(line)
+		if err == nil && bytes.Equal(key.Marshal(), pubKey.Marshal()) {
+			changed = true
+			continue
+		}
+		o = append(o, line...)
+		o = append(o, '\n')
+	}
+	return
+}
+
+func runRemoteCommand(client *ssh.Client, cmd string, stdin []byte) (o []byte, err error) {
+	session, err := client.NewSession()
+	if err != nil {
+		return
+	}
+	defer session.Close()
+
+	if stdin != nil {
+		session.Stdin = bytes.NewReader(stdin)
+	}
+
+	var stderr bytes.Buffer
+	session.Stderr = &stderr
+
+	o, err = session.Output(cmd)
+	if err != nil && stderr.Len() > 0 {
+		return o, fmt.Errorf("%w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return
+}
+
+// deployAuthorizedKey adds public key to (or removes it from) remote authorized_keys file,
+// returns false if file is not changed
+func deployAuthorizedKey(client *ssh.Client, pubKeyB []byte, revoke bool) (changed bool, err error) {
+	pubKey, _, _, _, err := ssh.ParseAuthorizedKey(pubKeyB)
+	if err != nil {
+		return
+	}
+
+	data, err := runRemoteCommand(client, deployReadCmd, nil)
+	if err != nil {
+		return false, fmt.Errorf("cannot read remote authorized_keys: %w", err)
+	}
+
+	if revoke {
+		data, changed = authorizedKeysRemove(data, pubKey)
+	} else {
+		data, changed = authorizedKeysAdd(data, pubKey, pubKeyB)
+	}
+
+	if !changed {
+		return
+	}
+
+	_, err = runRemoteCommand(client, deployWriteCmd, data)
+	if err != nil {
+		return false, fmt.Errorf("cannot write remote authorized_keys: %w", err)
+	}
+
+	return true, nil
+}
+
+// deployResult is structured result of deploy command
+type deployResult struct {
+	Key     string `json:"key" yaml:"key"`
+	Action  string `json:"action" yaml:"action"`
+	User    string `json:"user" yaml:"user"`
+	Host    string `json:"host" yaml:"host"`
+	Changed bool   `json:"changed" yaml:"changed"`
+}
+
+// knownHostKeyAlgorithms returns host key algorithms of known_hosts keys for address,
+// so server is asked for known key instead of its preferred one, nil if host is unknown
+// or has certificate authority entry
+func knownHostKeyAlgorithms(entries []knownHostEntry, addr string) (o []string) {
+	var seen = map[string]bool{}
+	for _, e := range entries {
+		if !e.matchesHost(addr) {
+			continue
+		}
+
+		switch e.marker {
+		case "":
+		case "cert-authority":
+			return nil
+		default:
+			continue
+		}
+
+		var algos = []string{e.key.Type()}
+		if e.key.Type() == ssh.KeyAlgoRSA {
+			algos = []string{ssh.KeyAlgoRSASHA512, ssh.KeyAlgoRSASHA256, ssh.KeyAlgoRSA}
+		}
+
+		for _, algo := range algos {
+			if !seen[algo] {
+				seen[algo] = true
+				o = append(o, algo)
+			}
+		}
+	}
+	return
+}
+
+// getDeployHostKeyCallback returns host key callback and host key algorithms for address
+func (s *gc) getDeployHostKeyCallback(c *cli.Context, addr string) (ssh.HostKeyCallback, []string, error) {
+	if c.Bool("insecure") {
+		s.log().Warn("remote host key verification is disabled")
+		return ssh.InsecureIgnoreHostKey(), nil, nil
+	}
+
+	var path = expandHomeDir(c.String("known-hosts"))
+	hostKeyCallback, err := knownhosts.New(path)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	entries, err := readKnownHostsFile(path)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return hostKeyCallback, knownHostKeyAlgorithms(entries, addr), nil
+}
+
+// Deploy - add public ssh-key to remote host authorized_keys (ssh-copy-id)
+func (s *gc) Deploy(c *cli.Context) error {
+	var revoke = c.Bool("revoke")
+	var target = c.Args().Get(1)
+	if target == "" {
+		return newUsageError("remote host must be set")
+	}
+
+	username, addr, err := parseDeployTarget(target, c.Int("port"))
+	if err != nil {
+		return err
+	}
+
+	s.log().Info("getting public ssh-key from gopass")
+	pubKey, err := s.ks.getPublicSSHKey(s.key)
+	if err != nil {
+		return err
+	}
+	pubKey = getPublicSSHKeyWithComment(pubKey, s.key)
+
+	hostKeyCallback, hostKeyAlgorithms, err := s.getDeployHostKeyCallback(c, addr)
+	if err != nil {
+		return err
+	}
+
+	s.log().Infof("connecting to %s@%s", username, addr)
+	client, err := ssh.Dial("tcp", addr, &ssh.ClientConfig{
+		User:              username,
+		Auth:              []ssh.AuthMethod{ssh.PublicKeysCallback(s.sa.signers)},
+		HostKeyCallback:   hostKeyCallback,
+		HostKeyAlgorithms: hostKeyAlgorithms,
+		Timeout:           deployDialTimeout,
+	})
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	if revoke {
+		s.log().Info("removing public ssh-key from remote authorized_keys")
+	} else {
+		s.log().Info("adding public ssh-key to remote authorized_keys")
+	}
+
+	changed, err := deployAuthorizedKey(client, pubKey, revoke)
+	if err != nil {
+		return err
+	}
+
+	if !changed {
+		s.log().Info("remote authorized_keys is up to date")
+	}
+
+	var action = "deployed"
+	if revoke {
+		action = "revoked"
+	}
+
+	return s.printResult(deployResult{
+		Key:     s.key,
+		Action:  action,
+		User:    username,
+		Host:    addr,
+		Changed: changed,
+	}, "")
+}
@@ -0,0 +1,231 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+// testSSHServer is in-process ssh server which understands only deploy commands
+// and keeps authorized_keys file in memory
+type testSSHServer struct {
+	mu             sync.Mutex
+	authorizedKeys []byte
+	readFails      bool
+	listener       net.Listener
+	config         *ssh.ServerConfig
+}
+
+func newTestSSHServer(t *testing.T, clientKey ssh.PublicKey, hostSigners ...ssh.Signer) *testSSHServer {
+	if len(hostSigners) == 0 {
+		hostSigner, _ := testSigner(t, sshKeyTypeEd25519)
+		hostSigners = append(hostSigners, hostSigner)
+	}
+
+	srv := &testSSHServer{}
+	srv.config = &ssh.ServerConfig{
+		PublicKeyCallback: func(_ ssh.ConnMetadata, key ssh.PublicKey) (*ssh.Permissions, error) {
+			if bytes.Equal(key.Marshal(), clientKey.Marshal()) {
+				return nil, nil
+			}
+			return nil, assert.AnError
+		},
+	}
+	for _, hostSigner := range hostSigners {
+		srv.config.AddHostKey(hostSigner)
+	}
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, err)
+	srv.listener = l
+
+	go srv.serve()
+	t.Cleanup(func() { l.Close() })
+	return srv
+}
+
+func (srv *testSSHServer) serve() {
+	for {
+		conn, err := srv.listener.Accept()
+		if err != nil {
+			return
+		}
+
+		go func() {
+			_, chans, reqs, err := ssh.NewServerConn(conn, srv.config)
+			if err != nil {
+				return
+			}
+			go ssh.DiscardRequests(reqs)
+
+			for newCh := range chans {
+				ch, reqs, err := newCh.Accept()
+				if err != nil {
+					continue
+				}
+				go srv.session(ch, reqs)
+			}
+		}()
+	}
+}
+
+func (srv *testSSHServer) session(ch ssh.Channel, reqs <-chan *ssh.Request) {
+	defer ch.Close()
+
+	for req := range reqs {
+		var payload struct{ Command string }
+		if req.Type != "exec" || ssh.Unmarshal(req.Payload, &payload) != nil {
+			req.Reply(false, nil)
+			continue
+		}
+		req.Reply(true, nil)
+
+		var status uint32
+		srv.mu.Lock()
+		switch payload.Command {
+		case deployReadCmd:
+			if srv.readFails {
+				ch.Stderr().Write([]byte("cat: .ssh/authorized_keys: Permission denied"))
+				status = 1
+				break
+			}
+			ch.Write(srv.authorizedKeys)
+		case deployWriteCmd:
+			srv.authorizedKeys, _ = io.ReadAll(ch)
+		default:
+			status = 127
+		}
+		srv.mu.Unlock()
+
+		ch.SendRequest("exit-status", false, ssh.Marshal(struct{ Status uint32 }{status}))
+		return
+	}
+}
+
+func TestDeployAuthorizedKey(t *testing.T) {
+	clientSigner, _ := testSigner(t, sshKeyTypeEd25519)
+	_, pubKey := testSigner(t, sshKeyTypeEd25519)
+	_, otherPubKey := testSigner(t, sshKeyTypeRsa)
+
+	srv := newTestSSHServer(t, clientSigner.PublicKey())
+	srv.authorizedKeys = otherPubKey
+
+	client, err := ssh.Dial("tcp", srv.listener.Addr().String(), &ssh.ClientConfig{
+		User:            "test",
+		Auth:            []ssh.AuthMethod{ssh.PublicKeys(clientSigner)},
+		HostKeyCallback: ssh.InsecureIgnoreHostKey(),
+	})
+	assert.Nil(t, err)
+	defer client.Close()
+
+	changed, err := deployAuthorizedKey(client, pubKey, false)
+	assert.Nil(t, err)
+	assert.True(t, changed)
+	assert.Equal(t, string(otherPubKey)+string(pubKey), string(srv.authorizedKeys))
+
+	changed, err = deployAuthorizedKey(client, pubKey, false)
+	assert.Nil(t, err)
+	assert.False(t, changed)
+
+	changed, err = deployAuthorizedKey(client, pubKey, true)
+	assert.Nil(t, err)
+	assert.True(t, changed)
+	assert.Equal(t, string(otherPubKey), string(srv.authorizedKeys))
+
+	changed, err = deployAuthorizedKey(client, pubKey, true)
+	assert.Nil(t, err)
+	assert.False(t, changed)
+
+	// unreadable authorized_keys is not replaced
+	srv.readFails = true
+	_, err = deployAuthorizedKey(client, pubKey, false)
+	assert.ErrorContains(t, err, "Permission denied")
+	assert.Equal(t, string(otherPubKey), string(srv.authorizedKeys))
+}
+
+func TestCommandsDeployKnownHostKeyAlgorithms(t *testing.T) {
+	s, ms, run := newTestGc()
+
+	privBytes, _, err := sshKeygen(sshKeyTypeEd25519, "", 0)
+	assert.Nil(t, err)
+	assert.Nil(t, s.sa.add(privBytes, "", "client", 0))
+	clientSigner, err := ssh.ParsePrivateKey(privBytes)
+	assert.Nil(t, err)
+
+	_, pubKey := testSigner(t, sshKeyTypeEd25519)
+	ms.publicKeys["ssh-keys/test/key"] = pubKey
+
+	// server prefers rsa host key, but only ed25519 one is known
+	rsaHostSigner, _ := testSigner(t, sshKeyTypeRsa)
+	ed25519HostSigner, _ := testSigner(t, sshKeyTypeEd25519)
+	srv := newTestSSHServer(t, clientSigner.PublicKey(), rsaHostSigner, ed25519HostSigner)
+
+	var addr = srv.listener.Addr().String()
+	var knownHostsFile = filepath.Join(t.TempDir(), "known_hosts")
+	var line = knownhosts.Line([]string{knownhosts.Normalize(addr)}, ed25519HostSigner.PublicKey())
+	assert.Nil(t, os.WriteFile(knownHostsFile, []byte(line+"\n"), 0o600))
+
+	_, err = run("deploy", "--known-hosts", knownHostsFile, "test/key", "test@"+addr)
+	assert.Nil(t, err)
+	assert.True(t, authorizedKeysContains(srv.authorizedKeys, mustParseAuthorizedKey(t, pubKey)))
+}
+
+func TestKnownHostKeyAlgorithms(t *testing.T) {
+	_, rsaKey := testSigner(t, sshKeyTypeRsa)
+	_, ed25519Key := testSigner(t, sshKeyTypeEd25519)
+
+	entries, err := parseKnownHosts([]byte(
+		"example.com " + string(rsaKey) + "\n" +
+			"example.com,[example.com]:2222 " + string(ed25519Key) + "\n" +
+			"@revoked example.com " + string(ed25519Key) + "\n" +
+			"@cert-authority *.example.org " + string(ed25519Key) + "\n"))
+	assert.Nil(t, err)
+
+	assert.Equal(t, []string{ssh.KeyAlgoRSASHA512, ssh.KeyAlgoRSASHA256, ssh.KeyAlgoRSA, ssh.KeyAlgoED25519},
+		knownHostKeyAlgorithms(entries, "example.com:22"))
+	assert.Equal(t, []string{ssh.KeyAlgoED25519}, knownHostKeyAlgorithms(entries, "example.com:2222"))
+	assert.Nil(t, knownHostKeyAlgorithms(entries, "host.example.org:22"))
+	assert.Nil(t, knownHostKeyAlgorithms(entries, "unknown.com:22"))
+}
+
+func mustParseAuthorizedKey(t *testing.T, b []byte) ssh.PublicKey {
+	key, _, _, _, err := ssh.ParseAuthorizedKey(b)
+	assert.Nil(t, err)
+	return key
+}
+
+func TestAuthorizedKeysRemoveLongLine(t *testing.T) {
+	_, pubKeyB := testSigner(t, sshKeyTypeEd25519)
+	pubKey, _, _, _, err := ssh.ParseAuthorizedKey(pubKeyB)
+	assert.Nil(t, err)
+
+	var long = "# " + strings.Repeat("x", 128*1024) + "\n"
+	o, changed := authorizedKeysRemove([]byte(long+string(pubKeyB)+"\n"+long), pubKey)
+	assert.True(t, changed)
+	assert.Equal(t, long+"\n"+long, string(o))
+	assert.True(t, authorizedKeysContains([]byte(long+string(pubKeyB)), pubKey))
+}
+
+func TestParseDeployTarget(t *testing.T) {
+	username, addr, err := parseDeployTarget("root@example.com", 22)
+	assert.Nil(t, err)
+	assert.Equal(t, "root", username)
+	assert.Equal(t, "example.com:22", addr)
+
+	_, addr, err = parseDeployTarget("root@example.com:2222", 22)
+	assert.Nil(t, err)
+	assert.Equal(t, "example.com:2222", addr)
+
+	_, addr, err = parseDeployTarget("root@[::1]", 2222)
+	assert.Nil(t, err)
+	assert.Equal(t, "[::1]:2222", addr)
+}
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"errors"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+
+	"github.com/urfave/cli/v2"
+	"golang.org/x/crypto/ssh/agent"
+)
+
+// ephemeralAgent is in-process ssh-agent served on temporary unix socket
+type ephemeralAgent struct {
+	*sshAgent
+	dir      string
+	listener net.Listener
+	closed   sync.Once
+	closeErr error
+}
+
+func newEphemeralAgent() (ea *ephemeralAgent, err error) {
+	dir, err := os.MkdirTemp("", appName+"-")
+	if err != nil {
+		return
+	}
+
+	l, err := net.Listen("unix", filepath.Join(dir, "agent.sock"))
+	if err != nil {
+		os.RemoveAll(dir)
+		return
+	}
+
+	ea = &ephemeralAgent{
+		sshAgent: newSSHKeyringAgent(),
+		dir:      dir,
+		listener: l,
+	}
+
+	go ea.serve()
+	return ea, nil
+}
+
+func (ea *ephemeralAgent) serve() {
+	for {
+		conn, err := ea.listener.Accept()
+		if err != nil {
+			return
+		}
+
+		go func() {
+			defer conn.Close()
+			_ = agent.ServeAgent(ea.agent, conn)
+		}()
+	}
+}
+
+func (ea *ephemeralAgent) socket() string {
+	return ea.listener.Addr().String()
+}
+
+// Close - stop serving agent and remove its socket, it can be called more than once
+func (ea *ephemeralAgent) Close() error {
+	ea.closed.Do(func() {
+		_ = ea.agent.RemoveAll()
+		ea.closeErr = ea.listener.Close()
+		if rmErr := os.RemoveAll(ea.dir); ea.closeErr == nil {
+			ea.closeErr = rmErr
+		}
+	})
+	return ea.closeErr
+}
+
+// getExitCode returns child exit code, killed child exits with 128+signal as in shell
+func getExitCode(exitErr *exec.ExitError) int {
+	if ws, ok := exitErr.Sys().(syscall.WaitStatus); ok && ws.Signaled() {
+		return 128 + int(ws.Signal())
+	}
+	return exitErr.ExitCode()
+}
+
+// getEnvWithSSHAgent returns environment variables with SSH_AUTH_SOCK replaced by socket
+func getEnvWithSSHAgent(environ []string, socket string) (o []string) {
+	for _, e := range environ {
+		if strings.HasPrefix(e, "SSH_AUTH_SOCK=") || strings.HasPrefix(e, "SSH_AGENT_PID=") {
+			continue
+		}
+		o = append(o, e)
+	}
+	return append(o, "SSH_AUTH_SOCK="+socket)
+}
+
+// Exec - run command with ephemeral ssh-agent holding selected ssh-keys
+func (s *gc) Exec(c *cli.Context) error {
+	var store = c.String("store")
+	var keys = c.StringSlice("key")
+	var lifetime = c.Int("lifetime")
+
+	if len(keys) == 0 {
+		return newUsageError("at least one ssh-key path must be set")
+	}
+
+	if c.NArg() == 0 {
+		return newUsageError("command must be set")
+	}
+
+	s.log().Info("starting ephemeral ssh-agent")
+	ea, err := newEphemeralAgent()
+	if err != nil {
+		return err
+	}
+	defer ea.Close()
+
+	for _, k := range keys {
+		var key = filepath.Join(store, k)
+
+		keyLifetime, err := s.getAgentLifetime(key, lifetime, false)
+		if err != nil {
+			return err
+		}
+
+		s.log().WithField("gkey", key).Info("adding private ssh-key to ephemeral ssh-agent")
+		err = s.addToAgent(ea, key, uint32(keyLifetime), false)
+		if err != nil {
+			return err
+		}
+	}
+
+	var args = c.Args().Slice()
+	cmd := exec.CommandContext(c.Context, args[0], args[1:]...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = s.stdout
+	cmd.Stderr = os.Stderr
+	cmd.Env = getEnvWithSSHAgent(os.Environ(), ea.socket())
+
+	s.log().Infof("running '%s'", strings.Join(args, " "))
+	err = cmd.Run()
+
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return cli.Exit("", getExitCode(exitErr))
+	}
+
+	return err
+}
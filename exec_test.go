@@ -0,0 +1,69 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/urfave/cli/v2"
+)
+
+func TestGetEnvWithSSHAgent(t *testing.T) {
+	var environ = []string{"HOME=/home/user", "SSH_AUTH_SOCK=/tmp/agent.1", "SSH_AGENT_PID=1", "PATH=/bin"}
+	assert.Equal(t, []string{"HOME=/home/user", "PATH=/bin", "SSH_AUTH_SOCK=/tmp/ea.sock"},
+		getEnvWithSSHAgent(environ, "/tmp/ea.sock"))
+	assert.Equal(t, "SSH_AUTH_SOCK=/tmp/agent.1", environ[1])
+}
+
+func TestEphemeralAgentClose(t *testing.T) {
+	ea, err := newEphemeralAgent()
+	assert.Nil(t, err)
+	assert.FileExists(t, ea.socket())
+
+	assert.Nil(t, ea.Close())
+	assert.NoFileExists(t, ea.socket())
+	assert.NoDirExists(t, ea.dir)
+	assert.Nil(t, ea.Close())
+}
+
+func TestCommandsExec(t *testing.T) {
+	_, _, run := newTestGc()
+
+	var exitCode int
+	var osExiter = cli.OsExiter
+	cli.OsExiter = func(code int) { exitCode = code }
+	t.Cleanup(func() { cli.OsExiter = osExiter })
+
+	t.Setenv("SSH_AUTH_SOCK", "/tmp/parent-agent.sock")
+
+	_, err := run("secret", "generate", "test/key")
+	assert.Nil(t, err)
+
+	out, err := run("exec", "--key", "test/key", "--", "sh", "-c", `echo "$SSH_AUTH_SOCK"; exit 3`)
+	assert.NotNil(t, err)
+	assert.Equal(t, 3, exitCode)
+
+	// SSH_AUTH_SOCK is replaced in child environment only, socket is removed after exit
+	var socket = strings.TrimSpace(out)
+	assert.Equal(t, "agent.sock", filepath.Base(socket))
+	assert.NoFileExists(t, socket)
+	assert.NoDirExists(t, filepath.Dir(socket))
+	assert.Equal(t, "/tmp/parent-agent.sock", os.Getenv("SSH_AUTH_SOCK"))
+
+	// killed child exits with 128+signal
+	_, err = run("exec", "--key", "test/key", "--", "sh", "-c", "kill -TERM $$")
+	assert.NotNil(t, err)
+	assert.Equal(t, 128+15, exitCode)
+
+	_, err = run("exec", "--key", "test/key", "--", "true")
+	assert.Nil(t, err)
+
+	if _, err := exec.LookPath("ssh-add"); err == nil {
+		out, err = run("exec", "--key", "test/key", "--", "ssh-add", "-l")
+		assert.Nil(t, err)
+		assert.Contains(t, out, getSSHKeyComment("ssh-keys/test/key"))
+	}
+}
@@ -0,0 +1,172 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/urfave/cli/v2"
+)
+
+const expiryDateFormat = "2006-01-02"
+
+// expiringResult is structured result of ssh-key which expires soon
+type expiringResult struct {
+	Key       string `json:"key" yaml:"key"`
+	Expires   string `json:"expires" yaml:"expires"`
+	Expired   bool   `json:"expired" yaml:"expired"`
+	Remaining int    `json:"remaining" yaml:"remaining"`
+}
+
+// parseDuration parses duration with days (`90d`) and weeks (`2w`) units in addition to time.ParseDuration units
+func parseDuration(in string) (time.Duration, error) {
+	var units = map[string]time.Duration{"d": 24 * time.Hour, "w": 7 * 24 * time.Hour}
+	for suffix, unit := range units {
+		if !strings.HasSuffix(in, suffix) {
+			continue
+		}
+		if n, err := strconv.Atoi(strings.TrimSuffix(in, suffix)); err == nil {
+			return time.Duration(n) * unit, nil
+		}
+	}
+
+	d, err := time.ParseDuration(in)
+	if err != nil {
+		return 0, newUsageError("invalid duration '%s' (e.g. 90d, 2w, 12h)", in)
+	}
+	return d, nil
+}
+
+// parseExpiry returns expiry time from duration relative to now, date (2006-01-02) or RFC3339 time
+func parseExpiry(in string, now time.Time) (time.Time, error) {
+	if t, err := parseMetadataTime(in); err == nil {
+		return t.UTC(), nil
+	}
+	if t, err := time.Parse(expiryDateFormat, in); err == nil {
+		return t, nil
+	}
+
+	d, err := parseDuration(in)
+	if err != nil {
+		return time.Time{}, newUsageError("invalid expiry '%s' (e.g. 90d, 2w, %s)", in, expiryDateFormat)
+	}
+	return now.Add(d).UTC().Truncate(time.Second), nil
+}
+
+// formatRemaining returns remaining validity as `in 13d` or `expired 2d ago`
+func formatRemaining(d time.Duration) string {
+	var abs = d
+	if abs < 0 {
+		abs = -abs
+	}
+
+	var s = abs.Round(time.Minute).String()
+	if abs >= 24*time.Hour {
+		s = fmt.Sprintf("%dd", int(abs.Hours()/24))
+	}
+
+	if d < 0 {
+		return "expired " + s + " ago"
+	}
+	return "in " + s
+}
+
+// getSSHKeyExpiry returns expiry time from ssh-key metadata, zero time if it is not set
+func (s *gc) getSSHKeyExpiry(key string) (time.Time, error) {
+	meta, err := s.ks.getMetadata(key)
+	if err != nil {
+		if errors.Is(err, errMetadataNotSupported) {
+			return time.Time{}, nil
+		}
+		return time.Time{}, err
+	}
+
+	expires := meta[gopassMetaExpires]
+	if expires == "" {
+		return time.Time{}, nil
+	}
+
+	t, err := parseMetadataTime(expires)
+	if err != nil {
+		return t, fmt.Errorf("invalid '%s' metadata: %w", gopassMetaExpires, err)
+	}
+	return t, nil
+}
+
+// getAgentLifetime caps ssh-agent lifetime (seconds, 0 is forever) at remaining ssh-key validity,
+// expired ssh-key is refused unless allowExpired is set
+func (s *gc) getAgentLifetime(key string, lifetime int, allowExpired bool) (int, error) {
+	expires, err := s.getSSHKeyExpiry(key)
+	if err != nil || expires.IsZero() {
+		return lifetime, err
+	}
+
+	var remaining = time.Until(expires)
+	if remaining <= 0 {
+		if !allowExpired {
+			return 0, newAppError(errCodeExpired, fmt.Errorf("ssh-key '%s' expired at %s", key, expires.Format(time.RFC3339)))
+		}
+
+		s.log().WithField("gkey", key).Warnf("ssh-key expired at %s", expires.Format(time.RFC3339))
+		return lifetime, nil
+	}
+
+	var seconds = int(remaining.Seconds())
+	if lifetime == 0 || lifetime > seconds {
+		s.log().WithField("gkey", key).Infof("lifetime is capped at remaining ssh-key validity (%s)", formatRemaining(remaining))
+		return seconds, nil
+	}
+	return lifetime, nil
+}
+
+// Expiring - show ssh-keys which expire soon or already expired
+func (s *gc) Expiring(c *cli.Context) (err error) {
+	var store = c.String("store")
+
+	within, err := parseDuration(c.String("within"))
+	if err != nil {
+		return err
+	}
+
+	s.log().Info("getting ssh-keys list from gopass")
+	paths, err := s.ks.list(store)
+	if err != nil {
+		return err
+	}
+
+	var now = time.Now()
+	var o = make([]expiringResult, 0)
+	for _, p := range paths {
+		var key = filepath.Join(store, p)
+
+		expires, err := s.getSSHKeyExpiry(key)
+		if err != nil {
+			s.log().WithField("gkey", key).Warnf("skipping: %s", err)
+			continue
+		}
+		if expires.IsZero() || expires.Sub(now) > within {
+			continue
+		}
+
+		o = append(o, expiringResult{
+			Key:       key,
+			Expires:   expires.Format(time.RFC3339),
+			Expired:   !expires.After(now),
+			Remaining: int(expires.Sub(now).Seconds()),
+		})
+	}
+
+	sort.Slice(o, func(i, j int) bool {
+		return o[i].Remaining < o[j].Remaining
+	})
+
+	var text strings.Builder
+	for _, res := range o {
+		fmt.Fprintf(&text, "%s\t%s\t%s\n", res.Key, res.Expires, formatRemaining(time.Duration(res.Remaining)*time.Second))
+	}
+	return s.printResult(o, text.String())
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseExpiry(t *testing.T) {
+	var now = time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	expires, err := parseExpiry("90d", now)
+	assert.Nil(t, err)
+	assert.Equal(t, time.Date(2024, 3, 31, 12, 0, 0, 0, time.UTC), expires)
+
+	expires, err = parseExpiry("2w", now)
+	assert.Nil(t, err)
+	assert.Equal(t, time.Date(2024, 1, 15, 12, 0, 0, 0, time.UTC), expires)
+
+	expires, err = parseExpiry("2024-06-01", now)
+	assert.Nil(t, err)
+	assert.Equal(t, time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC), expires)
+
+	_, err = parseExpiry("soon", now)
+	assert.Equal(t, errCodeInvalidArgument, getAppError(err).Code)
+
+	assert.Equal(t, "in 13d", formatRemaining(13*24*time.Hour+time.Hour))
+	assert.Equal(t, "expired 2h0m0s ago", formatRemaining(-2*time.Hour))
+}
+
+func TestCommandsExpiry(t *testing.T) {
+	_, ms, run := newTestGc()
+
+	_, err := run("secret", "generate", "--expires", "10d", "test/soon")
+	assert.Nil(t, err)
+	_, err = run("secret", "generate", "--expires", "90d", "test/later")
+	assert.Nil(t, err)
+
+	out, err := run("--output", "json", "expiring", "--within", "14d")
+	assert.Nil(t, err)
+
+	var expiring []expiringResult
+	assert.Nil(t, json.Unmarshal([]byte(out), &expiring))
+	assert.Len(t, expiring, 1)
+	assert.Equal(t, "ssh-keys/test/soon", expiring[0].Key)
+	assert.False(t, expiring[0].Expired)
+
+	out, err = run("--output", "json", "agent", "add", "--lifetime", "0", "test/soon")
+	assert.Nil(t, err)
+
+	var res sshKeyResult
+	assert.Nil(t, json.Unmarshal([]byte(out), &res))
+	assert.InDelta(t, 10*24*3600, res.Lifetime, 60)
+
+	ms.metadata["ssh-keys/test/soon"][gopassMetaExpires] = time.Now().Add(-time.Hour).UTC().Format(time.RFC3339)
+	_, err = run("agent", "add", "test/soon")
+	assert.Equal(t, errCodeExpired, getAppError(err).Code)
+
+	_, err = run("agent", "add", "--allow-expired", "test/soon")
+	assert.Nil(t, err)
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"strings"
+
+	"github.com/urfave/cli/v2"
+	"golang.org/x/crypto/ssh"
+)
+
+// randomart field size and symbols, same as `ssh-keygen -lv`
+const (
+	randomartWidth   = 17
+	randomartHeight  = 9
+	randomartSymbols = " .o+=*BOX@%&#/^SE"
+)
+
+// fingerprintResult is structured result of public ssh-key fingerprints
+type fingerprintResult struct {
+	Key       string `json:"key" yaml:"key"`
+	Type      string `json:"type" yaml:"type"`
+	Bits      int    `json:"bits" yaml:"bits"`
+	Comment   string `json:"comment" yaml:"comment"`
+	SHA256    string `json:"sha256" yaml:"sha256"`
+	MD5       string `json:"md5" yaml:"md5"`
+	Randomart string `json:"randomart" yaml:"randomart"`
+}
+
+// getRandomart returns OpenSSH "drunken bishop" randomart image of public key SHA256 digest
+func getRandomart(pubKey ssh.PublicKey) string {
+	var field [randomartWidth][randomartHeight]int
+	var last = len(randomartSymbols) - 1
+	var x, y = randomartWidth / 2, randomartHeight / 2
+
+	var digest = sha256.Sum256(pubKey.Marshal())
+	for _, input := range digest {
+		for b := 0; b < 4; b++ {
+			if input&0x1 != 0 {
+				x++
+			} else {
+				x--
+			}
+			if input&0x2 != 0 {
+				y++
+			} else {
+				y--
+			}
+
+			x = clamp(x, 0, randomartWidth-1)
+			y = clamp(y, 0, randomartHeight-1)
+			if field[x][y] < last-2 {
+				field[x][y]++
+			}
+			input >>= 2
+		}
+	}
+
+	field[randomartWidth/2][randomartHeight/2] = last - 1
+	field[x][y] = last
+
+	var title = fmt.Sprintf("[%s %d]", getSSHKeyTypeName(pubKey), getSSHKeyBits(pubKey))
+	if len(title) > randomartWidth-2 {
+		title = fmt.Sprintf("[%s]", getSSHKeyTypeName(pubKey))
+	}
+
+	var sb strings.Builder
+	sb.WriteString(randomartBorder(title))
+	for y := 0; y < randomartHeight; y++ {
+		sb.WriteByte('|')
+		for x := 0; x < randomartWidth; x++ {
+			sb.WriteByte(randomartSymbols[clamp(field[x][y], 0, last)])
+		}
+		sb.WriteString("|\n")
+	}
+	sb.WriteString(randomartBorder("[SHA256]"))
+	return sb.String()
+}
+
+func clamp(v, lo, hi int) int {
+	if v < lo {
+		return lo
+	}
+	if v > hi {
+		return hi
+	}
+	return v
+}
+
+// randomartBorder returns border line with centered title
+func randomartBorder(title string) string {
+	var left = (randomartWidth - len(title)) / 2
+	var right = clamp(randomartWidth-left-len(title), 0, randomartWidth)
+	return "+" + strings.Repeat("-", left) + title + strings.Repeat("-", right) + "+\n"
+}
+
+// normalizeFingerprint adds hash name to fingerprint without it (colon separated hex is MD5)
+func normalizeFingerprint(fingerprint string) string {
+	if strings.HasPrefix(fingerprint, "SHA256:") || strings.HasPrefix(fingerprint, "MD5:") {
+		return fingerprint
+	}
+	if strings.Count(fingerprint, ":") == 15 {
+		return "MD5:" + fingerprint
+	}
+	return "SHA256:" + fingerprint
+}
+
+// matchFingerprint checks public ssh-key has SHA256 or MD5 fingerprint, fingerprint must have hash name
+func matchFingerprint(pubKey ssh.PublicKey, fingerprint string) bool {
+	return ssh.FingerprintSHA256(pubKey) == fingerprint || "MD5:"+ssh.FingerprintLegacyMD5(pubKey) == fingerprint
+}
+
+// ShowFingerprint - show public ssh-key fingerprints and randomart
+func (s *gc) ShowFingerprint(c *cli.Context) error {
+	s.log().Info("getting public ssh-key from gopass")
+	pubKeyB, err := s.ks.getPublicSSHKey(s.key)
+	if err != nil {
+		return err
+	}
+
+	pubKey, _, _, _, err := ssh.ParseAuthorizedKey(pubKeyB)
+	if err != nil {
+		return err
+	}
+
+	var res = fingerprintResult{
+		Key:       s.key,
+		Type:      getSSHKeyTypeName(pubKey),
+		Bits:      getSSHKeyBits(pubKey),
+		Comment:   getPublicSSHKeyComment(pubKeyB, s.key),
+		SHA256:    ssh.FingerprintSHA256(pubKey),
+		MD5:       "MD5:" + ssh.FingerprintLegacyMD5(pubKey),
+		Randomart: getRandomart(pubKey),
+	}
+
+	var text = fmt.Sprintf("%d %s %s (%s)\n%d %s %s (%s)\n%s",
+		res.Bits, res.SHA256, res.Comment, res.Type,
+		res.Bits, res.MD5, res.Comment, res.Type,
+		res.Randomart)
+	return s.printResult(res, text)
+}
+
+// Find - find ssh-key path by public ssh-key fingerprint
+func (s *gc) Find(c *cli.Context) error {
+	var store = c.String("store")
+	var fingerprint = normalizeFingerprint(c.String("fingerprint"))
+
+	s.log().Infof("searching ssh-key with fingerprint %s", fingerprint)
+	key, err := findByFingerprint(s.ks, store, fingerprint)
+	if err != nil {
+		return err
+	}
+
+	return s.printResult(sshKeyResult{Key: key, Fingerprint: fingerprint}, key+"\n")
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testFingerprintPublicKey = "ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIASOCUItf4i45QiQ3U3Ma5LZPQ7+8vBY8iC2UUw60C47 me@x\n"
+
+func TestCommandsFingerprint(t *testing.T) {
+	_, ms, run := newTestGc()
+	assert.Nil(t, ms.setPublicSSHKey("ssh-keys/test/key", []byte(testFingerprintPublicKey)))
+
+	out, err := run("--output", "json", "secret", "key", "fingerprint", "test/key")
+	assert.Nil(t, err)
+
+	var res fingerprintResult
+	assert.Nil(t, json.Unmarshal([]byte(out), &res))
+	assert.Equal(t, "SHA256:1lICWoJiCQSjW9YghoajyiSUAtU6hoDHWmIWbntxo9M", res.SHA256)
+	assert.Equal(t, "MD5:66:f3:e5:cd:f5:ea:6b:42:5f:f6:9a:6a:98:97:d5:d6", res.MD5)
+	assert.Equal(t, 256, res.Bits)
+	assert.Equal(t, "me@x", res.Comment)
+
+	// ssh-keygen -lv output
+	assert.Equal(t, ""+
+		"+--[ED25519 256]--+\n"+
+		"|#**o. o          |\n"+
+		"|&@=o.+ .         |\n"+
+		"|@@oooo  . .      |\n"+
+		"|*== = .  +       |\n"+
+		"|*o = E  S .      |\n"+
+		"|... .  . .       |\n"+
+		"|                 |\n"+
+		"|                 |\n"+
+		"|                 |\n"+
+		"+----[SHA256]-----+\n", res.Randomart)
+
+	out, err = run("secret", "find", "--fingerprint", res.SHA256)
+	assert.Nil(t, err)
+	assert.Equal(t, "ssh-keys/test/key\n", out)
+
+	out, err = run("secret", "find", "--fingerprint", "66:f3:e5:cd:f5:ea:6b:42:5f:f6:9a:6a:98:97:d5:d6")
+	assert.Nil(t, err)
+	assert.Equal(t, "ssh-keys/test/key\n", out)
+
+	_, err = run("secret", "find", "--fingerprint", "SHA256:missing")
+	assert.Equal(t, errCodeNotFound, getAppError(err).Code)
+}
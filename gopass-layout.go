@@ -0,0 +1,387 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/gopasspw/gopass/pkg/gopass/secrets"
+	"github.com/urfave/cli/v2"
+)
+
+// ssh-key entry layouts:
+//
+// split - every part is separate secret: `key/password`, `key/ssh-key`, `key/ssh-key.pub`
+//
+// single - one secret `key` with passphrase as password line and base64 encoded
+// `ssh-key`, `ssh-key.pub` and metadata as fields
+//
+// part names can be changed per mount in config file
+const (
+	gopassLayoutSplit  = "split"
+	gopassLayoutSingle = "single"
+)
+
+var gopassLayouts = []string{gopassLayoutSplit, gopassLayoutSingle}
+
+func checkGopassLayout(layout string) error {
+	for _, l := range gopassLayouts {
+		if l == layout {
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown storage layout '%s' (%s)", layout, strings.Join(gopassLayouts, ", "))
+}
+
+// isSingleLayout detects layout of existing entry, configured layout is used for new entries
+func (gs gopassStorage) isSingleLayout(key string) (single bool, err error) {
+	var m = gs.mount(key)
+	for _, name := range []string{m.Password.Name, m.PrivateKey.Name, m.PublicKey.Name} {
+		split, err := gs.hasName(filepath.Join(key, name))
+		if err != nil || split {
+			return false, err
+		}
+	}
+
+	exists, err := gs.hasName(key)
+	if err != nil || exists {
+		return exists, err
+	}
+	return m.Layout == gopassLayoutSingle, nil
+}
+
+// getSingleEntry returns password and fields of single layout entry
+func (gs gopassStorage) getSingleEntry(key string) (password string, fields map[string]string, err error) {
+	s, err := gs.getSecret(key)
+	if err != nil {
+		return
+	}
+
+	fields = make(map[string]string)
+	for _, k := range s.Keys() {
+		fields[k], _ = s.Get(k)
+	}
+
+	return s.Password(), fields, nil
+}
+
+// newSingleEntrySecret creates single layout secret, fields are written in sorted order
+func (gs gopassStorage) newSingleEntrySecret(password string, fields map[string]string) (s *secrets.AKV, err error) {
+	s = secrets.NewAKV()
+	s.SetPassword(password)
+
+	var keys = make([]string, 0, len(fields))
+	for k := range fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		err = s.Set(k, fields[k])
+		if err != nil {
+			return
+		}
+	}
+
+	return s, nil
+}
+
+// updateSingleEntry changes single layout entry, entry without password and ssh-keys is deleted
+func (gs *gopassStorage) updateSingleEntry(key string, fn func(password *string, fields map[string]string) error) (err error) {
+	password, fields, err := gs.getSingleEntry(key)
+	if err != nil {
+		if err.Error() != ErrNotFound.Error() {
+			return
+		}
+		fields = make(map[string]string)
+	}
+
+	err = fn(&password, fields)
+	if err != nil {
+		return
+	}
+
+	var m = gs.mount(key)
+	_, hasPrivKey := fields[m.PrivateKey.Name]
+	_, hasPubKey := fields[m.PublicKey.Name]
+	if password == "" && !hasPrivKey && !hasPubKey {
+		return gs.delSecret(key)
+	}
+
+	s, err := gs.newSingleEntrySecret(password, fields)
+	if err != nil {
+		return
+	}
+
+	return gs.setSecret(key, s)
+}
+
+func (gs gopassStorage) getSinglePassword(key string) (p string, err error) {
+	p, _, err = gs.getSingleEntry(key)
+	return
+}
+
+func (gs *gopassStorage) setSinglePassword(key, password string) (err error) {
+	return gs.updateSingleEntry(key, func(p *string, _ map[string]string) error {
+		*p = password
+		return nil
+	})
+}
+
+func (gs gopassStorage) getSingleSSHKey(key, field string) (o []byte, err error) {
+	_, fields, err := gs.getSingleEntry(key)
+	if err != nil {
+		return
+	}
+
+	v, ok := fields[field]
+	if !ok {
+		return nil, ErrNotFound
+	}
+
+	return base64Decode([]byte(v))
+}
+
+func (gs *gopassStorage) setSingleSSHKey(key, field string, data []byte) (err error) {
+	encoded, err := base64Encode(data)
+	if err != nil {
+		return
+	}
+
+	return gs.updateSingleEntry(key, func(_ *string, fields map[string]string) error {
+		fields[field] = string(encoded)
+		return nil
+	})
+}
+
+// delSingleSSHKey removes ssh-key field, metadata is removed with public ssh-key
+func (gs *gopassStorage) delSingleSSHKey(key, field string) (err error) {
+	var m = gs.mount(key)
+	return gs.updateSingleEntry(key, func(_ *string, fields map[string]string) error {
+		if field != m.PublicKey.Name {
+			delete(fields, field)
+			return nil
+		}
+
+		for k := range fields {
+			if k != m.PrivateKey.Name {
+				delete(fields, k)
+			}
+		}
+		return nil
+	})
+}
+
+func (gs gopassStorage) getSingleMetadata(key string) (o map[string]string, err error) {
+	o = make(map[string]string)
+
+	_, fields, err := gs.getSingleEntry(key)
+	if err != nil {
+		if err.Error() == ErrNotFound.Error() {
+			return o, nil
+		}
+		return
+	}
+
+	var m = gs.mount(key)
+	for k, v := range fields {
+		if k == m.PrivateKey.Name || k == m.PublicKey.Name {
+			continue
+		}
+		o[k] = v
+	}
+
+	return
+}
+
+func (gs *gopassStorage) setSingleMetadata(key string, meta map[string]string) (err error) {
+	var m = gs.mount(key)
+	return gs.updateSingleEntry(key, func(_ *string, fields map[string]string) error {
+		if _, ok := fields[m.PublicKey.Name]; !ok {
+			return ErrNotFound
+		}
+
+		for mk, mv := range meta {
+			if mk == m.PrivateKey.Name || mk == m.PublicKey.Name {
+				return fmt.Errorf("metadata field '%s' is reserved", mk)
+			}
+
+			if mv == "" {
+				delete(fields, mk)
+				continue
+			}
+			fields[mk] = mv
+		}
+		return nil
+	})
+}
+
+// migrate moves ssh-key entry to another layout, returns false if entry already has it
+func (gs *gopassStorage) migrate(key, layout string) (changed bool, err error) {
+	single, err := gs.isSingleLayout(key)
+	if err != nil {
+		return
+	}
+
+	if single == (layout == gopassLayoutSingle) {
+		return false, nil
+	}
+
+	var m = gs.mount(key)
+	var parts = make(map[gopassPart][]byte)
+	password, err := gs.getPassword(key)
+	if err != nil && err.Error() != ErrNotFound.Error() {
+		return
+	}
+
+	for _, part := range []gopassPart{m.PrivateKey, m.PublicKey} {
+		var data []byte
+		if part == m.PrivateKey {
+			data, err = gs.getPrivateSSHKey(key)
+		} else {
+			data, err = gs.getPublicSSHKey(key)
+		}
+
+		if err != nil {
+			if err.Error() == ErrNotFound.Error() {
+				continue
+			}
+			return
+		}
+		parts[part] = data
+	}
+
+	if password == "" && len(parts) == 0 {
+		return false, ErrNotFound
+	}
+
+	meta, err := gs.getMetadata(key)
+	if err != nil {
+		return
+	}
+
+	return true, gs.transaction(func() error {
+		if single {
+			return gs.migrateToSplit(key, password, parts, meta)
+		}
+		return gs.migrateToSingle(key, password, parts, meta)
+	})
+}
+
+func (gs *gopassStorage) migrateToSingle(key, password string, parts map[gopassPart][]byte, meta map[string]string) error {
+	var fields = make(map[string]string)
+	for k, v := range meta {
+		fields[k] = v
+	}
+
+	for part, data := range parts {
+		encoded, err := base64Encode(data)
+		if err != nil {
+			return err
+		}
+		fields[part.Name] = string(encoded)
+	}
+
+	s, err := gs.newSingleEntrySecret(password, fields)
+	if err != nil {
+		return err
+	}
+
+	err = gs.setSecret(key, s)
+	if err != nil {
+		return err
+	}
+
+	for _, k := range []string{gs.getPasswordPath(key), gs.getPrivateKeyPath(key), gs.getPublicKeyPath(key)} {
+		err = gs.delSecret(k)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (gs *gopassStorage) migrateToSplit(key, password string, parts map[gopassPart][]byte, meta map[string]string) error {
+	if password != "" {
+		var s = secrets.NewAKV()
+		s.SetPassword(password)
+		err := gs.setSecret(gs.getPasswordPath(key), s)
+		if err != nil {
+			return err
+		}
+	}
+
+	for part, data := range parts {
+		var partMeta map[string]string
+		if part == gs.mount(key).PublicKey {
+			partMeta = meta
+		}
+
+		s, err := gs.newSSHKeySecret(part, data, partMeta)
+		if err != nil {
+			return err
+		}
+
+		err = gs.setSecret(gs.getPath(key, part.Name), s)
+		if err != nil {
+			return err
+		}
+	}
+
+	return gs.delSecret(key)
+}
+
+// Migrate - move ssh-key secrets to another storage layout
+func (s *gc) Migrate(c *cli.Context) error {
+	var layout = c.String("to")
+	var store = c.String("store")
+
+	gs, ok := s.ks.(*gopassStorage)
+	if !ok {
+		return fmt.Errorf("layout migration %w", errNotSupported)
+	}
+
+	err := checkGopassLayout(layout)
+	if err != nil {
+		return err
+	}
+
+	var paths = c.Args().Slice()
+	if len(paths) == 0 {
+		paths, err = gs.list(store)
+		if err != nil {
+			return err
+		}
+	}
+
+	if len(paths) == 0 {
+		return newAppError(errCodeNotFound, errors.New("no ssh-keys found"))
+	}
+
+	if !s.confirm("Are you sure you want to migrate %d ssh-key(s) in gopass ('%s') to %s layout?", len(paths), store, layout) {
+		return errCancelled
+	}
+
+	var o = make([]sshKeyResult, 0, len(paths))
+	for _, p := range paths {
+		s.key = filepath.Join(store, p)
+
+		s.log().Infof("migrating ssh-key to %s layout", layout)
+		changed, err := gs.migrate(s.key, layout)
+		if err != nil {
+			return err
+		}
+
+		if !changed {
+			s.log().Infof("ssh-key already has %s layout", layout)
+			o = append(o, sshKeyResult{Key: s.key, Action: "unchanged"})
+			continue
+		}
+		o = append(o, sshKeyResult{Key: s.key, Action: "migrated"})
+	}
+
+	return s.printResult(o, "")
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// ssh-key part encodings:
+//
+// base64 - body is base64 encoded with Content-Disposition and Content-Transfer-Encoding headers
+//
+// raw - secret is ssh-key file as is (e.g. saved with `gopass insert -m`), metadata is not supported
+const (
+	gopassEncodingBase64 = "base64"
+	gopassEncodingRaw    = "raw"
+)
+
+var errMetadataNotSupported = errors.New("metadata is not supported for raw encoded public ssh-key")
+
+// gopassMount describes how ssh-key entries are saved under gopass path prefix
+type gopassMount struct {
+	Layout     string     `json:"layout,omitempty" yaml:"layout,omitempty"`
+	Password   gopassPart `json:"password,omitempty" yaml:"password,omitempty"`
+	PrivateKey gopassPart `json:"private_key,omitempty" yaml:"private-key,omitempty"`
+	PublicKey  gopassPart `json:"public_key,omitempty" yaml:"public-key,omitempty"`
+}
+
+// gopassPart describes ssh-key entry part: secret name in split layout (field name in single layout)
+// and encoding, single layout fields are always base64 encoded
+type gopassPart struct {
+	Name     string `json:"name,omitempty" yaml:"name,omitempty"`
+	Encoding string `json:"encoding,omitempty" yaml:"encoding,omitempty"`
+}
+
+func (p gopassPart) withDefaults(name string) gopassPart {
+	if p.Name == "" {
+		p.Name = name
+	}
+	if p.Encoding == "" {
+		p.Encoding = gopassEncodingBase64
+	}
+	return p
+}
+
+func (p gopassPart) check() error {
+	if strings.Contains(p.Name, "/") || strings.Contains(p.Name, ":") {
+		return fmt.Errorf("invalid part name '%s'", p.Name)
+	}
+
+	switch p.Encoding {
+	case "", gopassEncodingBase64, gopassEncodingRaw:
+		return nil
+	}
+	return fmt.Errorf("unknown part encoding '%s' (%s, %s)", p.Encoding, gopassEncodingBase64, gopassEncodingRaw)
+}
+
+func (m gopassMount) check() error {
+	if m.Layout != "" {
+		if err := checkGopassLayout(m.Layout); err != nil {
+			return err
+		}
+	}
+
+	for _, p := range []gopassPart{m.Password, m.PrivateKey, m.PublicKey} {
+		if err := p.check(); err != nil {
+			return err
+		}
+	}
+
+	var names = map[string]bool{}
+	for _, p := range []gopassPart{m.Password, m.PrivateKey, m.PublicKey} {
+		if p.Name != "" && names[p.Name] {
+			return fmt.Errorf("part name '%s' is used twice", p.Name)
+		}
+		names[p.Name] = true
+	}
+
+	return nil
+}
+
+// checkGopassMounts validates mounts from config file
+func checkGopassMounts(mounts map[string]gopassMount) error {
+	for prefix, m := range mounts {
+		if err := m.check(); err != nil {
+			return fmt.Errorf("mount '%s': %w", prefix, err)
+		}
+	}
+	return nil
+}
+
+// mount returns settings of the longest mount prefix matching key, missing values are defaults
+func (gs gopassStorage) mount(key string) (m gopassMount) {
+	var matched = -1
+	for prefix, pm := range gs.mounts {
+		prefix = strings.Trim(prefix, "/")
+		if key != prefix && !strings.HasPrefix(key, prefix+"/") {
+			continue
+		}
+
+		if len(prefix) > matched {
+			m = pm
+			matched = len(prefix)
+		}
+	}
+
+	if m.Layout == "" {
+		m.Layout = gs.layout
+	}
+
+	m.Password = m.Password.withDefaults(gopassKeySuffixPassword)
+	m.PrivateKey = m.PrivateKey.withDefaults(gopassKeySuffixPrivateKey)
+	m.PublicKey = m.PublicKey.withDefaults(gopassKeySuffixPublicKey)
+	return
+}
+
+// isSplitPart checks secret name is part of split layout entry
+func (gs gopassStorage) isSplitPart(name string) bool {
+	var m = gs.mount(filepath.Dir(name))
+	switch filepath.Base(name) {
+	case m.Password.Name, m.PrivateKey.Name, m.PublicKey.Name:
+		return true
+	}
+	return false
+}
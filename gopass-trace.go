@@ -0,0 +1,62 @@
+package main
+
+import (
+	"context"
+	"time"
+
+	"github.com/apex/log"
+	"github.com/gopasspw/gopass/pkg/gopass"
+)
+
+// gopassTraceStore logs every gopass API call with its duration at debug level
+type gopassTraceStore struct {
+	gopass.Store
+	logger *log.Logger
+}
+
+func newGopassTraceStore(store gopass.Store, logger *log.Logger) gopass.Store {
+	if _, ok := store.(gopassTraceStore); ok {
+		return store
+	}
+	return gopassTraceStore{Store: store, logger: logger}
+}
+
+// trace returns function logging call duration, it should be deferred
+func (t gopassTraceStore) trace(method, name string, err *error) func() {
+	var start = time.Now()
+	return func() {
+		var e = t.logger.WithField("duration", time.Since(start).Round(time.Microsecond))
+		if name != "" {
+			e = e.WithField("name", name)
+		}
+		if *err != nil {
+			e = e.WithError(*err)
+		}
+		e.Debugf("gopass api %s", method)
+	}
+}
+
+func (t gopassTraceStore) List(ctx context.Context) (o []string, err error) {
+	defer t.trace("list", "", &err)()
+	return t.Store.List(ctx)
+}
+
+func (t gopassTraceStore) Get(ctx context.Context, name, revision string) (o gopass.Secret, err error) {
+	defer t.trace("get", name, &err)()
+	return t.Store.Get(ctx, name, revision)
+}
+
+func (t gopassTraceStore) Set(ctx context.Context, name string, sec gopass.Byter) (err error) {
+	defer t.trace("set", name, &err)()
+	return t.Store.Set(ctx, name, sec)
+}
+
+func (t gopassTraceStore) Remove(ctx context.Context, name string) (err error) {
+	defer t.trace("remove", name, &err)()
+	return t.Store.Remove(ctx, name)
+}
+
+func (t gopassTraceStore) Close(ctx context.Context) (err error) {
+	defer t.trace("close", "", &err)()
+	return t.Store.Close(ctx)
+}
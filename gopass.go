@@ -1,10 +1,12 @@
 package main
 
 import (
+	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"path/filepath"
-	"regexp"
+	"sort"
 	"strings"
 
 	"github.com/gopasspw/gopass/pkg/gopass"
@@ -18,14 +20,51 @@ const (
 	gopassKeySuffixPublicKey  = "ssh-key.pub"
 )
 
+const (
+	gopassHeaderContentDisposition      = "Content-Disposition"
+	gopassHeaderContentTransferEncoding = "Content-Transfer-Encoding"
+)
+
+// ssh-key metadata fields saved in public ssh-key secret
+const (
+	gopassMetaPrincipals = "principals"
+	gopassMetaEmail      = "email"
+	gopassMetaNamespaces = "namespaces"
+	gopassMetaCreated    = "created"
+	gopassMetaExpires    = "expires"
+)
+
 var (
 	// ErrNotFound is returned if an entry was not found.
 	ErrNotFound = fmt.Errorf("entry is not in the password store")
 )
 
 type gopassStorage struct {
-	ctx context.Context
-	api *api.Gopass
+	ctx    context.Context
+	api    gopass.Store
+	tx     *gopassTx
+	names  *gopassNames
+	layout string
+	mounts map[string]gopassMount
+}
+
+// gopassNames caches secret names listing, so store is listed once per command
+// instead of once per ssh-key part access, it is kept in sync on set and delete
+// (changed is set when secret is added or removed)
+type gopassNames struct {
+	set     map[string]bool
+	changed bool
+}
+
+// gopassTx keeps previous state of secrets changed in transaction
+type gopassTx struct {
+	backups []gopassTxBackup
+	seen    map[string]bool
+}
+
+type gopassTxBackup struct {
+	key    string
+	secret gopass.Secret // nil if secret did not exist
 }
 
 // Close -
@@ -33,22 +72,73 @@ func (gs *gopassStorage) Close() error {
 	return gs.api.Close(gs.ctx)
 }
 
+// listNames lists all secret names and refreshes names cache
+func (gs gopassStorage) listNames() (keys []string, err error) {
+	keys, err = gs.api.List(gs.ctx)
+	if err != nil || gs.names == nil {
+		return
+	}
+
+	gs.names.set = make(map[string]bool, len(keys))
+	for _, k := range keys {
+		gs.names.set[k] = true
+	}
+	return
+}
+
+// hasName checks secret exists, store is listed only if names are not cached yet
+func (gs gopassStorage) hasName(name string) (bool, error) {
+	if gs.names == nil || gs.names.set == nil {
+		keys, err := gs.listNames()
+		if err != nil || gs.names == nil {
+			return hasString(keys, name), err
+		}
+	}
+	return gs.names.set[name], nil
+}
+
+// setName updates names cache after secret is written or deleted, changed is set if secret is added or removed
+func (gs gopassStorage) setName(name string, exists, changed bool) {
+	if gs.names == nil {
+		return
+	}
+
+	gs.names.changed = gs.names.changed || changed
+	if gs.names.set == nil {
+		return
+	}
+
+	if exists {
+		gs.names.set[name] = true
+	} else {
+		delete(gs.names.set, name)
+	}
+}
+
 // get list of ssh key paths
 func (gs gopassStorage) list(prefix string) (ll []string, err error) {
-	var keys []string
-	var m = make(map[string]bool)
-
-	keys, err = gs.api.List(gs.ctx)
+	keys, err := gs.listNames()
 	if err != nil {
 		return
 	}
 
-	re := regexp.MustCompile(`^` + prefix + `/`)
+	return gs.listFrom(keys, prefix), nil
+}
+
+// listFrom returns ssh-key paths under prefix from gopass secret names
+func (gs gopassStorage) listFrom(keys []string, prefix string) (ll []string) {
+	var m = make(map[string]bool)
+
 	for _, key := range keys {
-		if !re.MatchString(key) {
+		if prefix != "" && !strings.HasPrefix(key, prefix+"/") {
 			continue
 		}
-		var dirPath = filepath.Dir(key)
+
+		// single layout entry is secret itself
+		var dirPath = key
+		if gs.isSplitPart(key) {
+			dirPath = filepath.Dir(key)
+		}
 		dirPath = strings.TrimPrefix(dirPath, fmt.Sprintf("%s/", prefix))
 
 		if _, ok := m[dirPath]; !ok {
@@ -60,21 +150,61 @@ func (gs gopassStorage) list(prefix string) (ll []string, err error) {
 	return
 }
 
+// get list of secret names under prefix
+func (gs gopassStorage) listSecrets(prefix string) (ll []string, err error) {
+	keys, err := gs.listNames()
+	if err != nil {
+		return
+	}
+
+	for _, key := range keys {
+		if strings.HasPrefix(key, prefix+"/") {
+			ll = append(ll, key)
+		}
+	}
+
+	return
+}
+
 // getting path
 func (gs gopassStorage) getPath(key, suffix string) string {
 	return filepath.Join(key, suffix)
 }
 
 func (gs gopassStorage) getPasswordPath(key string) string {
-	return gs.getPath(key, gopassKeySuffixPassword)
+	return gs.getPath(key, gs.mount(key).Password.Name)
 }
 
 func (gs gopassStorage) getPrivateKeyPath(key string) string {
-	return gs.getPath(key, gopassKeySuffixPrivateKey)
+	return gs.getPath(key, gs.mount(key).PrivateKey.Name)
 }
 
 func (gs gopassStorage) getPublicKeyPath(key string) string {
-	return gs.getPath(key, gopassKeySuffixPublicKey)
+	return gs.getPath(key, gs.mount(key).PublicKey.Name)
+}
+
+// partName returns secret name of ssh-key part, all parts of single layout entry are `key` secret
+func (gs gopassStorage) partName(key, part string) (name string, err error) {
+	var m = gs.mount(key)
+	switch part {
+	case gopassKeySuffixPassword:
+		name = m.Password.Name
+	case gopassKeySuffixPrivateKey:
+		name = m.PrivateKey.Name
+	case gopassKeySuffixPublicKey:
+		name = m.PublicKey.Name
+	default:
+		return "", fmt.Errorf("unknown ssh-key part '%s'", part)
+	}
+
+	single, err := gs.isSingleLayout(key)
+	if err != nil {
+		return
+	}
+	if single {
+		return key, nil
+	}
+	return gs.getPath(key, name), nil
 }
 
 func (gs *gopassStorage) getSecret(key string) (s gopass.Secret, err error) {
@@ -89,24 +219,124 @@ func (gs *gopassStorage) setSecret(key string, s gopass.Secret) (err error) {
 		if !secretNotFound {
 			return
 		}
+		es = nil
 	}
 
 	if !secretNotFound && gopassSecretsDiff(es, s) {
 		return nil
 	}
 
-	return gs.api.Set(gs.ctx, key, s)
+	gs.backup(key, es)
+	err = gs.api.Set(gs.ctx, key, s)
+	if err != nil {
+		return
+	}
+
+	gs.setName(key, true, secretNotFound)
+	return nil
 }
 
 func (gs *gopassStorage) delSecret(key string) (err error) {
+	if gs.tx != nil && !gs.tx.seen[key] {
+		es, err := gs.getSecret(key)
+		if err != nil {
+			if err.Error() == ErrNotFound.Error() {
+				return nil
+			}
+			return err
+		}
+		gs.backup(key, es)
+	}
+
 	err = gs.api.Remove(gs.ctx, key)
 	if err != nil && err.Error() != ErrNotFound.Error() {
 		return
 	}
+
+	gs.setName(key, false, true)
+	return nil
+}
+
+// transaction runs fn and restores all secrets changed by it to previous state if fn fails,
+// so ssh-key entry is never left half-updated
+func (gs *gopassStorage) transaction(fn func() error) (err error) {
+	if gs.tx != nil {
+		return fn()
+	}
+
+	gs.tx = &gopassTx{
+		seen: make(map[string]bool),
+	}
+	defer func() {
+		gs.tx = nil
+	}()
+
+	err = fn()
+	if err == nil {
+		return nil
+	}
+
+	if rbErr := gs.rollback(); rbErr != nil {
+		return fmt.Errorf("%w (rollback failed: %s)", err, rbErr)
+	}
+	return err
+}
+
+func (gs *gopassStorage) changed() bool {
+	return gs.names != nil && gs.names.changed
+}
+
+// backup saves previous secret state once per transaction
+func (gs *gopassStorage) backup(key string, s gopass.Secret) {
+	if gs.tx == nil || gs.tx.seen[key] {
+		return
+	}
+
+	gs.tx.seen[key] = true
+	gs.tx.backups = append(gs.tx.backups, gopassTxBackup{
+		key:    key,
+		secret: s,
+	})
+}
+
+// rollback restores secrets changed in transaction in reverse order
+func (gs *gopassStorage) rollback() error {
+	var errs []string
+	for i := len(gs.tx.backups) - 1; i >= 0; i-- {
+		var b = gs.tx.backups[i]
+		var err error
+		if b.secret == nil {
+			err = gs.api.Remove(gs.ctx, b.key)
+			if err != nil && err.Error() == ErrNotFound.Error() {
+				err = nil
+			}
+		} else {
+			err = gs.api.Set(gs.ctx, b.key, b.secret)
+		}
+
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %s", b.key, err))
+			continue
+		}
+		gs.setName(b.key, b.secret != nil, false)
+	}
+
+	gs.tx.backups = nil
+	if len(errs) > 0 {
+		return errors.New(strings.Join(errs, "; "))
+	}
 	return nil
 }
 
 func (gs gopassStorage) getPassword(key string) (p string, err error) {
+	single, err := gs.isSingleLayout(key)
+	if err != nil {
+		return
+	}
+	if single {
+		return gs.getSinglePassword(key)
+	}
+
 	k := gs.getPasswordPath(key)
 	s, err := gs.getSecret(k)
 	if err != nil {
@@ -119,6 +349,14 @@ func (gs gopassStorage) getPassword(key string) (p string, err error) {
 }
 
 func (gs *gopassStorage) setPassword(key, password string) (err error) {
+	single, err := gs.isSingleLayout(key)
+	if err != nil {
+		return
+	}
+	if single {
+		return gs.setSinglePassword(key, password)
+	}
+
 	k := gs.getPasswordPath(key)
 	var s = secrets.NewAKV()
 	s.SetPassword(password)
@@ -126,31 +364,105 @@ func (gs *gopassStorage) setPassword(key, password string) (err error) {
 }
 
 func (gs *gopassStorage) delPassword(key string) (err error) {
+	single, err := gs.isSingleLayout(key)
+	if err != nil {
+		return
+	}
+	if single {
+		return gs.setSinglePassword(key, "")
+	}
+
 	k := gs.getPasswordPath(key)
 	return gs.delSecret(k)
 }
 
+// getSSHKey returns ssh-key from secret, encoding is detected from secret content,
+// so keys written with any configured encoding can be read
 func (gs gopassStorage) getSSHKey(key string) (o []byte, err error) {
 	s, err := gs.getSecret(key)
 	if err != nil {
 		return
 	}
 
-	return base64Decode([]byte(s.Body()))
+	return decodeSSHKeySecret(s)
 }
 
-func (gs gopassStorage) setSSHKey(key, filename string, data []byte) (err error) {
-	var s = secrets.NewAKV()
-	err = s.Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s\"", filename))
+// isBase64Secret checks secret is base64 encoded file (gopass binary convention)
+func isBase64Secret(s gopass.Secret) bool {
+	for _, k := range []string{gopassHeaderContentTransferEncoding, strings.ToLower(gopassHeaderContentTransferEncoding)} {
+		cte, _ := s.Get(k)
+		if strings.EqualFold(cte, "base64") {
+			return true
+		}
+	}
+	return false
+}
+
+// decodeSSHKeySecret returns ssh-key from secret: base64 body if Content-Transfer-Encoding is set,
+// whole secret if it is raw PEM/OpenSSH key or base64 encoded key without headers
+func decodeSSHKeySecret(s gopass.Secret) (o []byte, err error) {
+	if isBase64Secret(s) {
+		return base64Decode([]byte(strings.Join(strings.Fields(s.Body()), "")))
+	}
+
+	var data = s.Bytes()
+	if isSSHKeyData(data) {
+		return data, nil
+	}
+
+	o, err = base64Decode(bytes.Join(bytes.Fields(data), nil))
+	if err != nil || !isSSHKeyData(o) {
+		return nil, errors.New("cannot detect ssh-key secret encoding")
+	}
+	return o, nil
+}
+
+func (gs gopassStorage) setSSHKey(key string, part gopassPart, data []byte) (err error) {
+	meta, err := gs.getSSHKeyMetadata(key, part)
 	if err != nil {
 		return
 	}
 
-	err = s.Set("Content-Transfer-Encoding", "Base64")
+	s, err := gs.newSSHKeySecret(part, data, meta)
 	if err != nil {
 		return
 	}
 
+	return gs.setSecret(key, s)
+}
+
+func (gs gopassStorage) newSSHKeySecret(part gopassPart, data []byte, meta map[string]string) (s *secrets.AKV, err error) {
+	if part.Encoding == gopassEncodingRaw {
+		if len(meta) > 0 {
+			return nil, errMetadataNotSupported
+		}
+		return secrets.ParseAKV(data), nil
+	}
+
+	s = secrets.NewAKV()
+	err = s.Set(gopassHeaderContentDisposition, fmt.Sprintf("attachment; filename=\"%s\"", part.Name))
+	if err != nil {
+		return
+	}
+
+	err = s.Set(gopassHeaderContentTransferEncoding, "Base64")
+	if err != nil {
+		return
+	}
+
+	var metaKeys = make([]string, 0, len(meta))
+	for k := range meta {
+		metaKeys = append(metaKeys, k)
+	}
+	sort.Strings(metaKeys)
+
+	for _, k := range metaKeys {
+		err = s.Set(k, meta[k])
+		if err != nil {
+			return
+		}
+	}
+
 	encoded, err := base64Encode(data)
 	if err != nil {
 		return
@@ -161,35 +473,174 @@ func (gs gopassStorage) setSSHKey(key, filename string, data []byte) (err error)
 		return
 	}
 
-	return gs.setSecret(key, s)
+	return s, nil
+}
+
+// getSSHKeyMetadata returns custom fields of ssh-key secret
+func (gs gopassStorage) getSSHKeyMetadata(key string, part gopassPart) (o map[string]string, err error) {
+	o = make(map[string]string)
+	if part.Encoding == gopassEncodingRaw {
+		return
+	}
+
+	s, err := gs.getSecret(key)
+	if err != nil {
+		if err.Error() == ErrNotFound.Error() {
+			return o, nil
+		}
+		return
+	}
+
+	// raw ssh-key lines can look like fields (e.g. PEM `DEK-Info: ...` header)
+	if !isBase64Secret(s) {
+		return
+	}
+
+	for _, k := range s.Keys() {
+		if k == gopassHeaderContentDisposition || k == gopassHeaderContentTransferEncoding {
+			continue
+		}
+		o[k], _ = s.Get(k)
+	}
+
+	return
 }
 
 func (gs gopassStorage) getPrivateSSHKey(key string) (o []byte, err error) {
+	single, err := gs.isSingleLayout(key)
+	if err != nil {
+		return
+	}
+	if single {
+		return gs.getSingleSSHKey(key, gs.mount(key).PrivateKey.Name)
+	}
+
 	k := gs.getPrivateKeyPath(key)
 	return gs.getSSHKey(k)
 }
 
 func (gs gopassStorage) getPublicSSHKey(key string) (o []byte, err error) {
+	single, err := gs.isSingleLayout(key)
+	if err != nil {
+		return
+	}
+	if single {
+		return gs.getSingleSSHKey(key, gs.mount(key).PublicKey.Name)
+	}
+
 	k := gs.getPublicKeyPath(key)
 	return gs.getSSHKey(k)
 }
 
 func (gs gopassStorage) setPrivateSSHKey(key string, data []byte) (err error) {
+	single, err := gs.isSingleLayout(key)
+	if err != nil {
+		return
+	}
+	if single {
+		return gs.setSingleSSHKey(key, gs.mount(key).PrivateKey.Name, data)
+	}
+
 	k := gs.getPrivateKeyPath(key)
-	return gs.setSSHKey(k, gopassKeySuffixPrivateKey, data)
+	return gs.setSSHKey(k, gs.mount(key).PrivateKey, data)
 }
 
 func (gs gopassStorage) setPublicSSHKey(key string, data []byte) (err error) {
+	single, err := gs.isSingleLayout(key)
+	if err != nil {
+		return
+	}
+	if single {
+		return gs.setSingleSSHKey(key, gs.mount(key).PublicKey.Name, data)
+	}
+
 	k := gs.getPublicKeyPath(key)
-	return gs.setSSHKey(k, gopassKeySuffixPublicKey, data)
+	return gs.setSSHKey(k, gs.mount(key).PublicKey, data)
+}
+
+// getMetadata returns ssh-key metadata
+func (gs gopassStorage) getMetadata(key string) (o map[string]string, err error) {
+	single, err := gs.isSingleLayout(key)
+	if err != nil {
+		return
+	}
+	if single {
+		return gs.getSingleMetadata(key)
+	}
+
+	var part = gs.mount(key).PublicKey
+	if part.Encoding == gopassEncodingRaw {
+		return nil, errMetadataNotSupported
+	}
+
+	k := gs.getPublicKeyPath(key)
+	return gs.getSSHKeyMetadata(k, part)
+}
+
+// setMetadata merges ssh-key metadata, empty value deletes field
+func (gs gopassStorage) setMetadata(key string, meta map[string]string) (err error) {
+	single, err := gs.isSingleLayout(key)
+	if err != nil {
+		return
+	}
+	if single {
+		return gs.setSingleMetadata(key, meta)
+	}
+
+	var part = gs.mount(key).PublicKey
+	if part.Encoding == gopassEncodingRaw {
+		return errMetadataNotSupported
+	}
+
+	k := gs.getPublicKeyPath(key)
+	data, err := gs.getSSHKey(k)
+	if err != nil {
+		return
+	}
+
+	existing, err := gs.getSSHKeyMetadata(k, part)
+	if err != nil {
+		return
+	}
+
+	for mk, mv := range meta {
+		if mv == "" {
+			delete(existing, mk)
+			continue
+		}
+		existing[mk] = mv
+	}
+
+	s, err := gs.newSSHKeySecret(part, data, existing)
+	if err != nil {
+		return
+	}
+
+	return gs.setSecret(k, s)
 }
 
 func (gs *gopassStorage) delPrivateSSHKey(key string) (err error) {
+	single, err := gs.isSingleLayout(key)
+	if err != nil {
+		return
+	}
+	if single {
+		return gs.delSingleSSHKey(key, gs.mount(key).PrivateKey.Name)
+	}
+
 	k := gs.getPrivateKeyPath(key)
 	return gs.delSecret(k)
 }
 
 func (gs *gopassStorage) delPublicSSHKey(key string) (err error) {
+	single, err := gs.isSingleLayout(key)
+	if err != nil {
+		return
+	}
+	if single {
+		return gs.delSingleSSHKey(key, gs.mount(key).PublicKey.Name)
+	}
+
 	k := gs.getPublicKeyPath(key)
 	return gs.delSecret(k)
 }
@@ -201,7 +652,9 @@ func newGopassStorage(ctx context.Context) (g *gopassStorage, err error) {
 	}
 
 	return &gopassStorage{
-		ctx: ctx,
-		api: gp,
+		ctx:    ctx,
+		api:    gp,
+		names:  &gopassNames{},
+		layout: gopassLayoutSplit,
 	}, nil
 }
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode"
+
+	"github.com/manifoldco/promptui"
+	"golang.org/x/crypto/ssh"
+)
+
+// pickerItem is ssh-key shown in interactive picker
+type pickerItem struct {
+	Path    string
+	Type    string
+	Comment string
+}
+
+// isTerminal checks stdin and stderr (where prompts are written) are terminals
+func isTerminal() bool {
+	for _, f := range []*os.File{os.Stdin, os.Stderr} {
+		info, err := f.Stat()
+		if err != nil || info.Mode()&os.ModeCharDevice == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// fuzzyMatch checks all pattern characters are found in s in the same order, case and spaces are ignored
+func fuzzyMatch(pattern, s string) bool {
+	var rs = []rune(strings.ToLower(s))
+	var i int
+	for _, p := range strings.ToLower(pattern) {
+		if unicode.IsSpace(p) {
+			continue
+		}
+
+		for i < len(rs) && rs[i] != p {
+			i++
+		}
+		if i == len(rs) {
+			return false
+		}
+		i++
+	}
+	return true
+}
+
+// getPickerItems returns ssh-keys under store with type and comment of public ssh-key
+func (s *gc) getPickerItems(store string) (o []pickerItem, err error) {
+	ll, err := s.ks.list(store)
+	if err != nil {
+		return
+	}
+
+	for _, p := range ll {
+		var item = pickerItem{Path: p}
+		var key = filepath.Join(store, p)
+
+		pubKey, err := s.ks.getPublicSSHKey(key)
+		if err != nil {
+			if err.Error() != ErrNotFound.Error() {
+				return nil, err
+			}
+			o = append(o, item)
+			continue
+		}
+
+		pubKey = getPublicSSHKeyWithComment(pubKey, key)
+		if pk, comment, _, _, err := ssh.ParseAuthorizedKey(pubKey); err == nil {
+			item.Type = getSSHKeyTypeName(pk)
+			item.Comment = comment
+		}
+
+		o = append(o, item)
+	}
+
+	return
+}
+
+// pickSSHKey asks to select ssh-key path under store with fuzzy search
+func (s *gc) pickSSHKey(store string) (string, error) {
+	s.log().Debugf("getting ssh-keys list from gopass ('%s')", store)
+	items, err := s.getPickerItems(store)
+	if err != nil {
+		return "", err
+	}
+
+	if len(items) == 0 {
+		return "", newAppError(errCodeNotFound, errors.New("no ssh-keys found"))
+	}
+
+	p := promptui.Select{
+		Label: "Select ssh-key",
+		Items: items,
+		Size:  10,
+		Templates: &promptui.SelectTemplates{
+			Label:    "{{ . }}",
+			Active:   "> {{ .Path | cyan }} {{ .Type | faint }} {{ .Comment | faint }}",
+			Inactive: "  {{ .Path }} {{ .Type | faint }} {{ .Comment | faint }}",
+			Selected: "ssh-key: {{ .Path }}",
+		},
+		Searcher: func(input string, index int) bool {
+			return fuzzyMatch(input, items[index].Path+" "+items[index].Comment)
+		},
+		StartInSearchMode: true,
+		Stdout:            os.Stderr,
+	}
+
+	i, _, err := p.Run()
+	switch err {
+	case nil:
+		return items[i].Path, nil
+	case promptui.ErrAbort, promptui.ErrInterrupt, promptui.ErrEOF:
+		return "", errCancelled
+	}
+	return "", err
+}
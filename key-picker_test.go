@@ -0,0 +1,15 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFuzzyMatch(t *testing.T) {
+	assert.True(t, fuzzyMatch("", "prod/web"))
+	assert.True(t, fuzzyMatch("pweb", "prod/web"))
+	assert.True(t, fuzzyMatch("PROD web", "prod/web"))
+	assert.False(t, fuzzyMatch("bew", "prod/web"))
+	assert.False(t, fuzzyMatch("prod/webs", "prod/web"))
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/gopasspw/gopass/pkg/gopass/api"
+	"github.com/urfave/cli/v2"
+	"golang.org/x/crypto/ssh"
+)
+
+var errNotSupported = errors.New("is not supported by storage backend")
+
+// keyStore is storage backend of ssh-keys, key is full ssh-key path (with store prefix),
+// missing entry part returns ErrNotFound, deleting missing part is not an error
+type keyStore interface {
+	// list returns ssh-key paths under prefix (relative to it), all ssh-key paths if prefix is empty
+	list(prefix string) ([]string, error)
+
+	getPassword(key string) (string, error)
+	setPassword(key, password string) error
+	delPassword(key string) error
+
+	getPrivateSSHKey(key string) ([]byte, error)
+	setPrivateSSHKey(key string, data []byte) error
+	delPrivateSSHKey(key string) error
+
+	// public ssh-key holds metadata, it is deleted with public ssh-key
+	getPublicSSHKey(key string) ([]byte, error)
+	setPublicSSHKey(key string, data []byte) error
+	delPublicSSHKey(key string) error
+
+	getMetadata(key string) (map[string]string, error)
+	// setMetadata merges ssh-key metadata, empty value deletes field
+	setMetadata(key string, meta map[string]string) error
+
+	// getKnownHosts returns known_hosts entries under prefix by entry id
+	getKnownHosts(prefix string) (map[string][]knownHostEntry, error)
+	// setKnownHosts replaces entries of id, empty entries delete it
+	setKnownHosts(prefix, id string, entries []knownHostEntry) error
+
+	// transaction reverts all changes made by fn if it fails
+	transaction(fn func() error) error
+
+	// partName returns storage name of ssh-key part (gopassKeySuffix* default part name),
+	// it names content copied to clipboard
+	partName(key, part string) (string, error)
+
+	// changed reports ssh-key parts were added or removed, cached listing is stale then
+	changed() bool
+}
+
+// findByFingerprint returns path of ssh-key under prefix which public key has fingerprint
+func findByFingerprint(ks keyStore, prefix, fingerprint string) (key string, err error) {
+	ll, err := ks.list(prefix)
+	if err != nil {
+		return
+	}
+
+	for _, p := range ll {
+		var k = filepath.Join(prefix, p)
+		pubKeyB, err := ks.getPublicSSHKey(k)
+		if err != nil {
+			if err.Error() == ErrNotFound.Error() {
+				continue
+			}
+			return "", err
+		}
+
+		pubKey, _, _, _, err := ssh.ParseAuthorizedKey(pubKeyB)
+		if err != nil {
+			continue
+		}
+
+		if matchFingerprint(pubKey, fingerprint) {
+			return k, nil
+		}
+	}
+
+	return "", ErrNotFound
+}
+
+// getKeyStoreID returns backend name and location, it identifies cached listing
+func getKeyStoreID(ks keyStore) string {
+	switch ks := ks.(type) {
+	case *gopassStorage:
+		// gopass config location (and legacy store dir) selects root store and mounts
+		var id = appBackendGopass + ":" + api.ConfigDir()
+		for _, env := range []string{"GOPASS_CONFIG", "PASSWORD_STORE_DIR"} {
+			if v := os.Getenv(env); v != "" {
+				id += ":" + v
+			}
+		}
+		return id
+	case *ageKeyStore:
+		return appBackendAge + ":" + ks.dir
+	}
+	return fmt.Sprintf("%T", ks)
+}
+
+// openKeyStore opens storage backend selected by flag values
+func (s *gc) openKeyStore(ctx context.Context, value func(name string) string) (keyStore, error) {
+	switch backend := value("backend"); backend {
+	case appBackendGopass:
+		var layout = value("layout")
+		err := checkGopassLayout(layout)
+		if err != nil {
+			return nil, err
+		}
+
+		gs, err := newGopassStorage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("cannot initialize gopass API: %w", err)
+		}
+		gs.mounts = s.cfg.Mounts
+		gs.layout = layout
+		return gs, nil
+	case appBackendAge:
+		return newAgeKeyStore(expandHomeDir(value("age-dir")), expandHomeDir(value("age-identity")))
+	default:
+		return nil, newUsageError("unknown storage backend '%s' (%s)", backend, strings.Join(appBackends, ", "))
+	}
+}
+
+// openContextKeyStore opens storage backend if it is not opened yet
+func (s *gc) openContextKeyStore(c *cli.Context) (err error) {
+	if s.ks != nil {
+		return nil
+	}
+
+	s.ks, err = s.openKeyStore(c.Context, func(name string) string {
+		return s.getFlagValue(c, name)
+	})
+	return
+}
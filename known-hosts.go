@@ -0,0 +1,565 @@
+package main
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/gopasspw/gopass/pkg/gopass/secrets"
+	"github.com/urfave/cli/v2"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+const (
+	knownHostsHashedPrefix = "|1|"
+	knownHostsHashedDir    = "hashed"
+	defaultKnownHostsFile  = "~/.ssh/known_hosts"
+)
+
+var knownHostIDInvalidChars = regexp.MustCompile(`[^A-Za-z0-9._-]`)
+
+type knownHostEntry struct {
+	marker  string
+	hosts   []string
+	key     ssh.PublicKey
+	comment string
+}
+
+// knownHostResult is structured result of known_hosts entry
+type knownHostResult struct {
+	Marker      string   `json:"marker,omitempty" yaml:"marker,omitempty"`
+	Hosts       []string `json:"hosts" yaml:"hosts"`
+	Type        string   `json:"type" yaml:"type"`
+	Fingerprint string   `json:"fingerprint" yaml:"fingerprint"`
+	Comment     string   `json:"comment,omitempty" yaml:"comment,omitempty"`
+	Line        string   `json:"line" yaml:"line"`
+}
+
+// knownHostsResult is structured result of known_hosts changes
+type knownHostsResult struct {
+	Action    string `json:"action" yaml:"action"`
+	File      string `json:"file,omitempty" yaml:"file,omitempty"`
+	Entries   int    `json:"entries" yaml:"entries"`
+	Skipped   int    `json:"skipped,omitempty" yaml:"skipped,omitempty"`
+	Conflicts int    `json:"conflicts,omitempty" yaml:"conflicts,omitempty"`
+}
+
+// knownHostConflictResult is structured result of conflicting known_hosts entries
+type knownHostConflictResult struct {
+	Source      string          `json:"source" yaml:"source"`
+	Entry       knownHostResult `json:"entry" yaml:"entry"`
+	Conflicting knownHostResult `json:"conflicting" yaml:"conflicting"`
+}
+
+func (e knownHostEntry) result() knownHostResult {
+	return knownHostResult{
+		Marker:      e.marker,
+		Hosts:       e.hosts,
+		Type:        e.key.Type(),
+		Fingerprint: ssh.FingerprintSHA256(e.key),
+		Comment:     e.comment,
+		Line:        e.String(),
+	}
+}
+
+// String returns entry in known_hosts line format
+func (e knownHostEntry) String() string {
+	var parts []string
+	if e.marker != "" {
+		parts = append(parts, "@"+e.marker)
+	}
+
+	parts = append(parts, strings.Join(e.hosts, ","))
+	parts = append(parts, strings.TrimSpace(string(ssh.MarshalAuthorizedKey(e.key))))
+
+	if e.comment != "" {
+		parts = append(parts, e.comment)
+	}
+
+	return strings.Join(parts, " ")
+}
+
+// id returns name of gopass secret where entry is stored
+func (e knownHostEntry) id() string {
+	var host = e.hosts[0]
+	if strings.HasPrefix(host, knownHostsHashedPrefix) {
+		return filepath.Join(knownHostsHashedDir, getHash(host)[:16])
+	}
+	return knownHostIDInvalidChars.ReplaceAllString(host, "_")
+}
+
+func (e knownHostEntry) sameKey(o knownHostEntry) bool {
+	return e.marker == o.marker && bytes.Equal(e.key.Marshal(), o.key.Marshal())
+}
+
+// matchesHost - check if entry host patterns match hostname (or hashed hostname)
+func (e knownHostEntry) matchesHost(host string) bool {
+	return knownHostsMatch(e.hosts, knownhosts.Normalize(host))
+}
+
+// conflictsWith - entries have key with same type for the same host, but keys are different
+func (e knownHostEntry) conflictsWith(o knownHostEntry) bool {
+	if e.marker != "" || o.marker != "" {
+		return false
+	}
+
+	if e.key.Type() != o.key.Type() || e.sameKey(o) {
+		return false
+	}
+
+	for _, b := range o.hosts {
+		if !strings.HasPrefix(b, "!") && knownHostsMatch(e.hosts, b) {
+			return true
+		}
+	}
+	for _, a := range e.hosts {
+		if !strings.HasPrefix(a, "!") && knownHostsMatch(o.hosts, a) {
+			return true
+		}
+	}
+	return false
+}
+
+// hashed returns entry copy with all non-wildcard hosts hashed, hashes of the same host in known
+// entries are reused, so all entries of host have the same hashed name (and secret name)
+func (e knownHostEntry) hashed(known ...knownHostEntry) knownHostEntry {
+	var hosts = make([]string, 0, len(e.hosts))
+	for _, h := range e.hosts {
+		if strings.HasPrefix(h, knownHostsHashedPrefix) || strings.ContainsAny(h, "*?!") {
+			hosts = append(hosts, h)
+			continue
+		}
+		hosts = append(hosts, knownHostHash(h, known))
+	}
+
+	e.hosts = hosts
+	return e
+}
+
+// knownHostHash returns hashed host from known entries or hashes it with new salt
+func knownHostHash(host string, known []knownHostEntry) string {
+	for _, k := range known {
+		for _, kh := range k.hosts {
+			if strings.HasPrefix(kh, knownHostsHashedPrefix) && knownHostHashMatch(kh, host) {
+				return kh
+			}
+		}
+	}
+	return knownhosts.HashHostname(host)
+}
+
+// knownHostsMatch checks host matches known_hosts pattern list (openssh wildcards and negation),
+// hashed patterns match host name, hashed host matches hashed pattern with the same salt
+// or plain host name (hashes with different salts can not be compared without host name)
+func knownHostsMatch(patterns []string, host string) bool {
+	var hostHashed = strings.HasPrefix(host, knownHostsHashedPrefix)
+	var plain []string
+	var hashedMatch bool
+	for _, p := range patterns {
+		switch {
+		case strings.HasPrefix(p, knownHostsHashedPrefix):
+			hashedMatch = hashedMatch || p == host || knownHostHashMatch(p, host)
+		case hostHashed:
+			hashedMatch = hashedMatch || (!strings.HasPrefix(p, "!") && knownHostHashMatch(host, p))
+		default:
+			plain = append(plain, p)
+		}
+	}
+
+	if hostHashed {
+		return hashedMatch
+	}
+
+	// negated pattern excludes host even if hashed pattern matches it
+	for _, p := range plain {
+		if strings.HasPrefix(p, "!") && sshWildcardMatch(p[1:], host) {
+			return false
+		}
+	}
+	return hashedMatch || sshPatternListMatch(plain, host)
+}
+
+func knownHostHashMatch(hashed, host string) bool {
+	parts := strings.Split(hashed, "|")
+	if len(parts) != 4 {
+		return false
+	}
+
+	salt, err := base64.StdEncoding.DecodeString(parts[2])
+	if err != nil {
+		return false
+	}
+
+	hash, err := base64.StdEncoding.DecodeString(parts[3])
+	if err != nil {
+		return false
+	}
+
+	mac := hmac.New(sha1.New, salt)
+	mac.Write([]byte(host))
+	return hmac.Equal(mac.Sum(nil), hash)
+}
+
+func parseKnownHosts(data []byte) (o []knownHostEntry, err error) {
+	for len(data) > 0 {
+		var e knownHostEntry
+		e.marker, e.hosts, e.key, e.comment, data, err = ssh.ParseKnownHosts(data)
+		if err == io.EOF {
+			return o, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		o = append(o, e)
+	}
+	return o, nil
+}
+
+func formatKnownHosts(entries []knownHostEntry) []byte {
+	var buf bytes.Buffer
+	for _, e := range entries {
+		buf.WriteString(e.String())
+		buf.WriteByte('\n')
+	}
+	return buf.Bytes()
+}
+
+// getKnownHosts returns all known_hosts entries saved under prefix grouped by secret name
+func (gs *gopassStorage) getKnownHosts(prefix string) (o map[string][]knownHostEntry, err error) {
+	names, err := gs.listSecrets(prefix)
+	if err != nil {
+		return
+	}
+
+	o = make(map[string][]knownHostEntry)
+	for _, name := range names {
+		s, err := gs.getSecret(name)
+		if err != nil {
+			return nil, err
+		}
+
+		entries, err := parseKnownHosts([]byte(s.Body()))
+		if err != nil {
+			return nil, fmt.Errorf("cannot parse known hosts secret '%s': %w", name, err)
+		}
+
+		id := strings.TrimPrefix(name, prefix+"/")
+		o[id] = entries
+	}
+	return
+}
+
+func (gs *gopassStorage) setKnownHosts(prefix, id string, entries []knownHostEntry) (err error) {
+	var k = filepath.Join(prefix, id)
+	if len(entries) == 0 {
+		return gs.delSecret(k)
+	}
+
+	var s = secrets.NewAKV()
+	_, err = s.Write(formatKnownHosts(entries))
+	if err != nil {
+		return
+	}
+
+	return gs.setSecret(k, s)
+}
+
+func readKnownHostsFile(path string) (o []knownHostEntry, err error) {
+	var data []byte
+	if path == "-" {
+		data, err = getDataFromStdIn()
+	} else {
+		data, err = os.ReadFile(path)
+	}
+	if err != nil {
+		return
+	}
+
+	return parseKnownHosts(data)
+}
+
+func sortedKnownHostIDs(m map[string][]knownHostEntry) []string {
+	var ids = make([]string, 0, len(m))
+	for id := range m {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+func flattenKnownHosts(m map[string][]knownHostEntry) (o []knownHostEntry) {
+	for _, id := range sortedKnownHostIDs(m) {
+		o = append(o, m[id]...)
+	}
+	return
+}
+
+// KnownHostsList - show known_hosts entries saved in gopass
+func (s *gc) KnownHostsList(c *cli.Context) error {
+	var prefix = c.String("prefix")
+	var host = c.Args().Get(0)
+
+	s.log().Info("getting known hosts from gopass")
+	stored, err := s.ks.getKnownHosts(prefix)
+	if err != nil {
+		return err
+	}
+
+	var o = make([]knownHostResult, 0)
+	var text strings.Builder
+	for _, e := range flattenKnownHosts(stored) {
+		if host != "" && !e.matchesHost(host) {
+			continue
+		}
+		o = append(o, e.result())
+		text.WriteString(e.String() + "\n")
+	}
+
+	return s.printResult(o, text.String())
+}
+
+// KnownHostsImport - merge entries from local known_hosts file to gopass
+func (s *gc) KnownHostsImport(c *cli.Context) error {
+	var prefix = c.String("prefix")
+	var file = expandHomeDir(c.String("file"))
+	var hash = c.Bool("hash")
+	var force = c.Bool("force")
+
+	s.log().Infof("reading known hosts from '%s'", file)
+	local, err := readKnownHostsFile(file)
+	if err != nil {
+		return err
+	}
+
+	s.log().Info("getting known hosts from gopass")
+	stored, err := s.ks.getKnownHosts(prefix)
+	if err != nil {
+		return err
+	}
+
+	var changed = make(map[string]bool)
+	var added, skipped, conflicts int
+
+	for _, e := range local {
+		var duplicate bool
+		var conflicted []string
+
+		for id, entries := range stored {
+			for _, se := range entries {
+				if se.sameKey(e) && (se.matchesHost(e.hosts[0]) || se.String() == e.String()) {
+					duplicate = true
+				}
+				if se.conflictsWith(e) {
+					conflicted = append(conflicted, id)
+				}
+			}
+		}
+
+		if duplicate {
+			skipped++
+			continue
+		}
+
+		if len(conflicted) > 0 {
+			conflicts++
+			s.log().Warnf("host key conflict for '%s' (%s): already stored in %s",
+				strings.Join(e.hosts, ","), e.key.Type(), strings.Join(conflicted, ", "))
+			if !force {
+				continue
+			}
+
+			for _, id := range conflicted {
+				var kept []knownHostEntry
+				for _, se := range stored[id] {
+					if !se.conflictsWith(e) {
+						kept = append(kept, se)
+					}
+				}
+				stored[id] = kept
+				changed[id] = true
+			}
+		}
+
+		if hash {
+			e = e.hashed(flattenKnownHosts(stored)...)
+		}
+		var id = e.id()
+
+		stored[id] = append(stored[id], e)
+		changed[id] = true
+		added++
+	}
+
+	if len(changed) == 0 {
+		s.log().Infof("nothing to import (skipped: %d, conflicts: %d)", skipped, conflicts)
+		return s.printResult(knownHostsResult{Action: "imported", Skipped: skipped, Conflicts: conflicts}, "")
+	}
+
+	if !s.confirm("Are you sure you want to save %d known hosts entries to gopass ('%s')?", added, prefix) {
+		return errCancelled
+	}
+
+	for _, id := range sortedKnownHostIDs(stored) {
+		if !changed[id] {
+			continue
+		}
+
+		s.log().Infof("saving known hosts entry '%s' to gopass", id)
+		err = s.ks.setKnownHosts(prefix, id, stored[id])
+		if err != nil {
+			return err
+		}
+	}
+
+	s.log().Infof("imported known hosts (added: %d, skipped: %d, conflicts: %d)", added, skipped, conflicts)
+	return s.printResult(knownHostsResult{Action: "imported", Entries: added, Skipped: skipped, Conflicts: conflicts}, "")
+}
+
+// KnownHostsExport - write consolidated known_hosts file from gopass entries
+func (s *gc) KnownHostsExport(c *cli.Context) error {
+	var prefix = c.String("prefix")
+	var file = expandHomeDir(c.String("file"))
+	var hash = c.Bool("hash")
+
+	s.log().Info("getting known hosts from gopass")
+	stored, err := s.ks.getKnownHosts(prefix)
+	if err != nil {
+		return err
+	}
+
+	var entries = flattenKnownHosts(stored)
+	if hash {
+		for i := range entries {
+			entries[i] = entries[i].hashed()
+		}
+	}
+
+	if file == "-" {
+		var o = make([]knownHostResult, 0, len(entries))
+		for _, e := range entries {
+			o = append(o, e.result())
+		}
+		return s.printResult(o, string(formatKnownHosts(entries)))
+	}
+
+	if !s.confirm("Are you sure you want to overwrite '%s' with %d known hosts entries?", file, len(entries)) {
+		return errCancelled
+	}
+
+	s.log().Infof("writing known hosts to '%s'", file)
+	err = os.MkdirAll(filepath.Dir(file), 0700)
+	if err != nil {
+		return err
+	}
+
+	err = os.WriteFile(file, formatKnownHosts(entries), 0600)
+	if err != nil {
+		return err
+	}
+
+	return s.printResult(knownHostsResult{Action: "exported", File: file, Entries: len(entries)}, "")
+}
+
+// KnownHostsDelete - delete host entries from gopass
+func (s *gc) KnownHostsDelete(c *cli.Context) error {
+	var prefix = c.String("prefix")
+	var host = c.Args().Get(0)
+	if host == "" {
+		return newUsageError("host must be set")
+	}
+
+	s.log().Info("getting known hosts from gopass")
+	stored, err := s.ks.getKnownHosts(prefix)
+	if err != nil {
+		return err
+	}
+
+	if !s.confirm("Are you sure you want to DELETE known hosts entries for '%s' from gopass?", host) {
+		return errCancelled
+	}
+
+	var deleted int
+	for _, id := range sortedKnownHostIDs(stored) {
+		var kept []knownHostEntry
+		for _, e := range stored[id] {
+			if !e.matchesHost(host) {
+				kept = append(kept, e)
+			}
+		}
+
+		if len(kept) == len(stored[id]) {
+			continue
+		}
+
+		deleted += len(stored[id]) - len(kept)
+		s.log().Infof("deleting known hosts entry '%s' from gopass", id)
+		err = s.ks.setKnownHosts(prefix, id, kept)
+		if err != nil {
+			return err
+		}
+	}
+
+	return s.printResult(knownHostsResult{Action: "deleted", Entries: deleted}, "")
+}
+
+// KnownHostsConflicts - show conflicting host keys in gopass and local known_hosts file
+func (s *gc) KnownHostsConflicts(c *cli.Context) error {
+	var prefix = c.String("prefix")
+	var file = expandHomeDir(c.String("file"))
+
+	s.log().Info("getting known hosts from gopass")
+	stored, err := s.ks.getKnownHosts(prefix)
+	if err != nil {
+		return err
+	}
+
+	var entries = flattenKnownHosts(stored)
+	var local []knownHostEntry
+	if file != "" {
+		s.log().Infof("reading known hosts from '%s'", file)
+		local, err = readKnownHostsFile(file)
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
+
+	var o = make([]knownHostConflictResult, 0)
+	var text strings.Builder
+	for i, a := range entries {
+		for _, b := range entries[i+1:] {
+			if a.conflictsWith(b) {
+				o = append(o, knownHostConflictResult{Source: "gopass", Entry: a.result(), Conflicting: b.result()})
+				fmt.Fprintf(&text, "gopass: %s\ngopass: %s\n\n", a.String(), b.String())
+			}
+		}
+
+		for _, b := range local {
+			if a.conflictsWith(b) {
+				o = append(o, knownHostConflictResult{Source: "local", Entry: a.result(), Conflicting: b.result()})
+				fmt.Fprintf(&text, "gopass: %s\nlocal:  %s\n\n", a.String(), b.String())
+			}
+		}
+	}
+
+	err = s.printResult(o, text.String())
+	if err != nil {
+		return err
+	}
+
+	if len(o) > 0 {
+		return fmt.Errorf("found %d known hosts conflicts", len(o))
+	}
+
+	s.log().Info("no conflicts found")
+	return nil
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func testKnownHostsLine(t *testing.T, hosts string) string {
+	_, pubBytes, err := sshKeygenEd25519("")
+	assert.Nil(t, err)
+
+	return fmt.Sprintf("%s %s", hosts, strings.TrimSpace(string(pubBytes)))
+}
+
+func TestKnownHostsConflicts(t *testing.T) {
+	data := strings.Join([]string{
+		testKnownHostsLine(t, "example.com,1.2.3.4"),
+		testKnownHostsLine(t, "1.2.3.4"),
+		testKnownHostsLine(t, "[example.com]:2222"),
+	}, "\n")
+
+	entries, err := parseKnownHosts([]byte(data))
+	assert.Nil(t, err)
+	assert.Len(t, entries, 3)
+
+	assert.True(t, entries[0].conflictsWith(entries[1]))
+	assert.False(t, entries[0].conflictsWith(entries[2]))
+	assert.False(t, entries[0].conflictsWith(entries[0]))
+}
+
+func TestKnownHostsHashed(t *testing.T) {
+	entries, err := parseKnownHosts([]byte(testKnownHostsLine(t, "example.com")))
+	assert.Nil(t, err)
+
+	plain := entries[0]
+	hashed := plain.hashed()
+	assert.True(t, strings.HasPrefix(hashed.hosts[0], knownHostsHashedPrefix))
+	assert.True(t, hashed.matchesHost("example.com"))
+	assert.False(t, hashed.matchesHost("example.org"))
+
+	other, err := parseKnownHosts([]byte(testKnownHostsLine(t, "example.com")))
+	assert.Nil(t, err)
+	assert.True(t, hashed.conflictsWith(other[0]))
+
+	// secret name is derived from stored hashed line, unsalted host hash is not used
+	stored, err := parseKnownHosts([]byte(hashed.String()))
+	assert.Nil(t, err)
+	assert.Equal(t, hashed.id(), stored[0].id())
+	assert.NotContains(t, hashed.id(), getHash("example.com")[:16])
+
+	// other entries of the host reuse stored hash and secret name
+	rehashed := other[0].hashed(stored...)
+	assert.Equal(t, hashed.hosts, rehashed.hosts)
+	assert.Equal(t, hashed.id(), rehashed.id())
+	assert.True(t, rehashed.conflictsWith(hashed))
+}
+
+func TestKnownHostsPatterns(t *testing.T) {
+	entries, err := parseKnownHosts([]byte(strings.Join([]string{
+		testKnownHostsLine(t, "*.example.com,!bastion.example.com"),
+		testKnownHostsLine(t, "web?.example.com"),
+		testKnownHostsLine(t, "bastion.example.com"),
+	}, "\n")))
+	assert.Nil(t, err)
+
+	assert.True(t, entries[0].matchesHost("web1.example.com"))
+	assert.False(t, entries[0].matchesHost("bastion.example.com"))
+	assert.False(t, entries[0].matchesHost("example.org"))
+	assert.True(t, entries[1].matchesHost("web1.example.com"))
+	assert.False(t, entries[1].matchesHost("web10.example.com"))
+
+	assert.True(t, entries[0].conflictsWith(entries[1]))
+	assert.False(t, entries[0].conflictsWith(entries[2]))
+
+	hashed := entries[2].hashed()
+	assert.False(t, entries[0].conflictsWith(hashed))
+	assert.True(t, hashed.matchesHost("bastion.example.com"))
+}
@@ -3,6 +3,7 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
 	"os"
@@ -17,7 +18,10 @@ import (
 )
 
 const (
-	appName = "gopass-ssh-add"
+	appName         = "gopass-ssh-add"
+	appStoreDefault = "ssh-keys"
+	appStoreEnv     = "GOPASS_SSH_ADD_STORE"
+	appLayoutEnv    = "GOPASS_SSH_ADD_LAYOUT"
 )
 
 // Version is the released version of gopass.
@@ -50,34 +54,90 @@ func main() {
 		ctx = ctxutil.WithStdin(ctx, true)
 	}
 
-	gs, err := newGopassStorage(ctx)
+	cfg, err := loadConfig(defaultConfigFile(), projectConfigFile())
 	if err != nil {
-		fmt.Printf("Failed to initialize gopass API: %s\n", err)
+		fmt.Fprintf(os.Stderr, "Failed to load config: %s\n", err)
 		os.Exit(1)
 	}
 
-	sshAgentObj, err := newSSHAgent()
+	// called by git as `gpg.ssh.program`
+	if isSSHKeygenCompatCall(os.Args[1:]) {
+		// signing falls back to stored keys without ssh-agent
+		sshAgentObj, err := newSSHAgent()
+		if err != nil {
+			sshAgentObj = newSSHKeyringAgent()
+		}
+
+		gc := &gc{
+			sa:     sshAgentObj,
+			cfg:    cfg,
+			stdout: os.Stdout,
+			logger: apexlog.Logger{
+				Handler: apexlogcli.New(os.Stderr),
+				Level:   apexlog.ErrorLevel,
+			},
+		}
+
+		var value = gc.getDefaultFlagValue(newApp(gc))
+		gc.ks, err = gc.openKeyStore(ctx, value)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to open storage backend: %s\n", err)
+			os.Exit(1)
+		}
+		os.Exit(gc.SSHKeygenCompat(value("store"), os.Args[1:]))
+	}
+
+	sshAgentObj, err := newKeyAgent()
 	if err != nil {
-		fmt.Printf("Failed to open ssh-agent: %s\n", err)
+		fmt.Fprintf(os.Stderr, "Failed to open ssh-agent: %s\n", err)
 		os.Exit(1)
 	}
 
 	cb, err := newClipboard(ctx)
 	if err != nil {
-		fmt.Printf("Failed to open clipboard agent: %s\n", err)
+		fmt.Fprintf(os.Stderr, "Failed to open clipboard agent: %s\n", err)
 		os.Exit(1)
 	}
 
 	logger := apexlog.Logger{
-		Handler: apexlogcli.New(os.Stdout),
+		Handler: apexlogcli.New(os.Stderr),
 		Level:   apexlog.InfoLevel,
 	}
 
 	gc := &gc{
-		gs:     gs,
 		sa:     sshAgentObj,
 		cb:     cb,
+		cfg:    cfg,
 		logger: logger,
+		stdout: os.Stdout,
+	}
+
+	app := newApp(gc)
+
+	// urfave/cli cannot complete flag values
+	if gc.completeFlagValue(app, os.Args) {
+		return
+	}
+
+	if err := app.RunContext(ctx, os.Args); err != nil {
+		if gc.structured() {
+			_ = gc.printError(err)
+			os.Exit(1)
+		}
+
+		if errors.Is(err, errCancelled) {
+			return
+		}
+		log.Fatal(err)
+	}
+}
+
+// newApp returns cli application with all commands
+func newApp(gc *gc) *cli.App {
+	// `-v` is verbose flag
+	cli.VersionFlag = &cli.BoolFlag{
+		Name:  "version",
+		Usage: "print the version",
 	}
 
 	app := cli.NewApp()
@@ -86,20 +146,81 @@ func main() {
 	app.Usage = `Use "gopass" as storage for ssh keys`
 	app.Description = "" +
 		"This command allows you to generate ssh keys, save it to gopass store " +
-		"and add it to ssh-agent."
+		"and add it to ssh-agent.\n\n" +
+		"It also accepts `ssh-keygen -Y sign|verify|find-principals|check-novalidate` arguments, " +
+		"so it can be used as git `gpg.ssh.program` to sign commits with ssh-keys saved in gopass " +
+		"(`" + appStoreEnv + "` environment variable sets the store).\n\n" +
+		"Flag defaults (also per ssh-key path pattern), secret part names and encodings can be set in config file " +
+		"(`" + appConfigPath(appConfigFilename) + "` or `" + appConfigEnv + "` environment variable, " +
+		"overridden by `" + appConfigProjectFilename + "` in current or parent directory). " +
+		"Every flag default can also be set with `" + appConfigEnvPrefix + "<FLAG>` environment variable, it overrides config file values (command line flags override both).\n\n" +
+		"Without gopass ssh-keys can be saved to directory of files encrypted with age (`--backend age`), " +
+		"every file is encrypted to X25519 recipients of `--age-identity` file (create it with `age-keygen -o <file>`)."
 	app.EnableBashCompletion = true
+	app.UseShortOptionHandling = true
+	app.After = gc.After
 	app.Flags = []cli.Flag{
 		&cli.StringFlag{
 			Name:        "store",
-			Value:       "ssh-keys",
-			DefaultText: "ssh-keys",
+			Value:       appStoreDefault,
+			DefaultText: appStoreDefault,
+			EnvVars:     []string{appStoreEnv},
 			Usage:       "first part of path to find the secret",
 		},
+		&cli.StringFlag{
+			Name:        "layout",
+			Value:       gopassLayoutSplit,
+			DefaultText: gopassLayoutSplit,
+			EnvVars:     []string{appLayoutEnv},
+			Usage:       "storage layout of new ssh-key secrets (split, single)",
+		},
+		&cli.StringFlag{
+			Name:        "backend",
+			Value:       appBackendGopass,
+			DefaultText: appBackendGopass,
+			Usage:       "storage backend of ssh-keys (gopass, age)",
+		},
+		&cli.StringFlag{
+			Name:        "age-dir",
+			Value:       appAgeDirDefault,
+			DefaultText: appAgeDirDefault,
+			Usage:       "directory of age encrypted ssh-keys (age backend)",
+		},
+		&cli.StringFlag{
+			Name:        "age-identity",
+			Value:       appAgeIdentityDefault,
+			DefaultText: appAgeIdentityDefault,
+			Usage:       "age identity file used to decrypt and encrypt ssh-keys (age backend)",
+		},
+		&cli.StringFlag{
+			Name:        "policy",
+			Value:       appPolicyDefault,
+			DefaultText: appPolicyDefault,
+			Usage:       "policy file checked on generate and insert (not checked if it does not exist)",
+		},
+		&cli.StringFlag{
+			Name:        "output",
+			Value:       appOutputText,
+			DefaultText: appOutputText,
+			Usage:       "command output format (text, json, yaml)",
+		},
 		&cli.BoolFlag{
 			Name:    "quiet",
 			Value:   false,
 			Aliases: []string{"q", "silent"},
-			Usage:   "do not write logs to stdout",
+			Usage:   "do not write logs to stderr",
+		},
+		&cli.BoolFlag{
+			Name:    "verbose",
+			Value:   false,
+			Aliases: []string{"v"},
+			Usage:   "write debug logs, repeat (-vv) to trace gopass api calls",
+		},
+		&cli.StringFlag{
+			Name:        "log-format",
+			Value:       appLogFormatText,
+			DefaultText: appLogFormatText,
+			Usage:       "log format (text, json)",
 		},
 		&cli.BoolFlag{
 			Name:    "yes",
@@ -123,7 +244,9 @@ func main() {
 					Description: "Add ssh-key to ssh-agent",
 					Usage:       "add ssh-key to ssh-agent",
 					UsageText: "`gopass-ssh-add --store=ssh-keys agent add path/to/ssh/key` # Add ssh-key to agent forever\n\n" +
-						"`gopass-ssh-add --store=ssh-keys agent add --time=300 path/to/ssh/key` # Add ssh-key to agent for 5 minutes",
+						"`gopass-ssh-add --store=ssh-keys agent add --time=300 path/to/ssh/key` # Add ssh-key to agent for 5 minutes" +
+						"\n\n" +
+						"Lifetime of ssh-key with `expires` metadata is capped at remaining validity, expired ssh-key is refused",
 					Aliases:      []string{},
 					Hidden:       false,
 					Action:       gc.SSHAdd,
@@ -136,6 +259,17 @@ func main() {
 							Aliases: []string{"time", "t"},
 							Usage:   "set a maximum lifetime when adding identities to an agent.",
 						},
+						&cli.BoolFlag{
+							Name:    "confirm",
+							Value:   false,
+							Aliases: []string{"c"},
+							Usage:   "require confirmation before every use of the identity",
+						},
+						&cli.BoolFlag{
+							Name:  "allow-expired",
+							Value: false,
+							Usage: "add expired ssh-key with a warning",
+						},
 					},
 				},
 				{
@@ -205,6 +339,123 @@ func main() {
 					BashComplete: gc.PathAutocomplete,
 					Flags:        append(appSSHKeygenFlags, appPasswordFlags...),
 				},
+				// find ssh-key by fingerprint
+				{
+					Name:        "find",
+					Description: "Find ssh-key path which public ssh-key has fingerprint (SHA256 or MD5)",
+					Usage:       "find ssh-key path by public ssh-key fingerprint",
+					UsageText: "`gopass-ssh-add --store=ssh-keys secret find --fingerprint SHA256:uNiVztksCsDhcc0u9e8BujQXVUpKZIDTMczCvj3tD2s`" +
+						"\n\n" +
+						"`gopass-ssh-add --store=ssh-keys secret find --fingerprint MD5:16:27:ac:a5:76:28:2d:36:63:1b:56:4d:eb:df:a6:48`",
+					Hidden:  false,
+					Action:  gc.Find,
+					Before:  gc.BeforeBase,
+					Aliases: []string{},
+					Flags: []cli.Flag{
+						&cli.StringFlag{
+							Name:     "fingerprint",
+							Aliases:  []string{"f"},
+							Usage:    "public ssh-key fingerprint",
+							Required: true,
+						},
+					},
+				},
+				// search ssh-keys by public parts and metadata
+				{
+					Name: "search",
+					Description: "Search ssh-keys by path, key type, comment, fingerprint, metadata fields and expiry. " +
+						"Patterns support '*' and '?', only public ssh-keys and metadata are read",
+					Usage: "search ssh-keys by path, type, comment, fingerprint or metadata",
+					UsageText: "`gopass-ssh-add --store=ssh-keys secret search --type ed25519 --comment '*@example.com'`" +
+						"\n\n" +
+						"`gopass-ssh-add --store=ssh-keys secret search --path 'prod/*' --meta principals='*deploy*' --expires-within 30d`",
+					Hidden:  false,
+					Action:  gc.Search,
+					Before:  gc.BeforeBase,
+					Aliases: []string{},
+					Flags: []cli.Flag{
+						&cli.StringFlag{
+							Name:  "path",
+							Usage: "ssh-key path pattern (relative to store)",
+						},
+						&cli.StringFlag{
+							Name:    "type",
+							Aliases: []string{"t"},
+							Usage:   "ssh-key type pattern (ed25519, rsa, ecdsa, ...)",
+						},
+						&cli.StringFlag{
+							Name:    "comment",
+							Aliases: []string{"C"},
+							Usage:   "public ssh-key comment pattern",
+						},
+						&cli.StringFlag{
+							Name:    "fingerprint",
+							Aliases: []string{"f"},
+							Usage:   "public ssh-key fingerprint (SHA256 or MD5)",
+						},
+						&cli.StringSliceFlag{
+							Name:    "meta",
+							Aliases: []string{"m"},
+							Usage:   "metadata field filter `name=pattern` or `name` if field is set (can be repeated)",
+						},
+						&cli.StringFlag{
+							Name:  "expires-within",
+							Usage: "ssh-keys which expire within period (e.g. 30d, 2w), including expired",
+						},
+						&cli.BoolFlag{
+							Name:  "expired",
+							Value: false,
+							Usage: "only expired ssh-keys",
+						},
+					},
+				},
+				// check passphrases and private ssh-keys
+				{
+					Name:        "audit",
+					Description: "Check passphrase strength (zxcvbn), unencrypted private ssh-keys, weak RSA sizes, DSA and legacy PEM formats and duplicate passphrases (all ssh-keys in store if path is not set), exit with non-zero code if issues are found",
+					Usage:       "check passphrases and private ssh-keys",
+					UsageText: "`gopass-ssh-add --store=ssh-keys secret audit`" +
+						"\n\n" +
+						"`gopass-ssh-add --store=ssh-keys --output json secret audit --min-score 4 path/to/ssh/key`",
+					Hidden:       false,
+					Action:       gc.Audit,
+					Before:       gc.BeforeBase,
+					Aliases:      []string{"check"},
+					BashComplete: gc.PathAutocomplete,
+					Flags: []cli.Flag{
+						&cli.IntFlag{
+							Name:  "min-score",
+							Value: 3,
+							Usage: "minimal zxcvbn passphrase score (0-4)",
+						},
+						&cli.IntFlag{
+							Name:  "min-rsa-bits",
+							Value: 3072,
+							Usage: "minimal RSA ssh-key size",
+						},
+					},
+				},
+				// move secrets between storage layouts
+				{
+					Name:        "migrate",
+					Description: "Move ssh-key secrets to another storage layout (all ssh-keys in store if path is not set)",
+					Usage:       "move ssh-key secrets to another storage layout",
+					UsageText: "`gopass-ssh-add --store=ssh-keys secret migrate --to single path/to/ssh/key` # one secret with password, ssh-keys and metadata" +
+						"\n\n" +
+						"`gopass-ssh-add --store=ssh-keys secret migrate --to split` # separate password, ssh-key and ssh-key.pub secrets",
+					Hidden:       false,
+					Action:       gc.Migrate,
+					Before:       gc.BeforeBase,
+					Aliases:      []string{},
+					BashComplete: gc.PathAutocomplete,
+					Flags: []cli.Flag{
+						&cli.StringFlag{
+							Name:     "to",
+							Usage:    "target storage layout (split, single)",
+							Required: true,
+						},
+					},
+				},
 				// password section
 				{
 					Name:        "password",
@@ -253,15 +504,61 @@ func main() {
 							Flags:        append([]cli.Flag{}, appPasswordFlags...),
 						},
 						{
-							Name:         "insert",
-							Description:  "Insert ssh-key password from stdin",
-							Usage:        "insert ssh-key password from stdin",
-							UsageText:    "`echo \"some-password\" | gopass-ssh-add --store=ssh-keys secret password insert path/to/ssh/key/secret`",
+							Name:        "insert",
+							Description: "Insert ssh-key password from pinentry, terminal prompt (without echo) or stdin",
+							Usage:       "insert ssh-key password",
+							UsageText: "`gopass-ssh-add --store=ssh-keys secret password insert path/to/ssh/key/secret` # ask password twice" +
+								"\n\n" +
+								"`gopass-ssh-add --store=ssh-keys secret password insert < password.txt path/to/ssh/key/secret` # trailing newline is removed",
 							Hidden:       false,
 							Action:       gc.InsertPassword,
 							Before:       gc.Before,
 							Aliases:      []string{"import"},
 							BashComplete: gc.PathAutocomplete,
+							Flags:        append([]cli.Flag{}, appPasswordInputFlags...),
+						},
+					},
+				},
+				// metadata section
+				{
+					Name:        "metadata",
+					Description: "Manage ssh-key metadata (principals, email, namespaces, created, expires, ...)",
+					Usage:       "manage ssh-key metadata",
+					Hidden:      false,
+					Aliases:     []string{"meta"},
+					Subcommands: []*cli.Command{
+						{
+							Name:         "show",
+							Description:  "Show ssh-key metadata",
+							Usage:        "show ssh-key metadata",
+							UsageText:    "`gopass-ssh-add --store=ssh-keys secret metadata show path/to/ssh/key/secret`",
+							Hidden:       false,
+							Action:       gc.ShowMetadata,
+							Before:       gc.Before,
+							Aliases:      []string{"ls", "list"},
+							BashComplete: gc.PathAutocomplete,
+						},
+						{
+							Name:         "set",
+							Description:  "Set ssh-key metadata fields",
+							Usage:        "set ssh-key metadata fields",
+							UsageText:    "`gopass-ssh-add --store=ssh-keys secret metadata set path/to/ssh/key/secret principals=dev@example.com namespaces=git`",
+							Hidden:       false,
+							Action:       gc.SetMetadata,
+							Before:       gc.Before,
+							Aliases:      []string{},
+							BashComplete: gc.PathAutocomplete,
+						},
+						{
+							Name:         "delete",
+							Description:  "Delete ssh-key metadata fields",
+							Usage:        "delete ssh-key metadata fields",
+							UsageText:    "`gopass-ssh-add --store=ssh-keys secret metadata delete path/to/ssh/key/secret namespaces`",
+							Hidden:       false,
+							Action:       gc.DeleteMetadata,
+							Before:       gc.Before,
+							Aliases:      []string{"remove", "del", "rm"},
+							BashComplete: gc.PathAutocomplete,
 						},
 					},
 				},
@@ -272,6 +569,17 @@ func main() {
 					Hidden:      false,
 					Aliases:     []string{"ssh-key"},
 					Subcommands: []*cli.Command{
+						{
+							Name:         "fingerprint",
+							Description:  "Show SHA256 and MD5 fingerprints and randomart of public ssh-key (same as `ssh-keygen -lv`)",
+							Usage:        "show public ssh-key fingerprints and randomart",
+							UsageText:    "`gopass-ssh-add --store=ssh-keys secret key fingerprint path/to/ssh/key/secret`",
+							Hidden:       false,
+							Action:       gc.ShowFingerprint,
+							Before:       gc.Before,
+							Aliases:      []string{"fp"},
+							BashComplete: gc.PathAutocomplete,
+						},
 						// delete ssh-key (private and puplic) from secret
 						{
 							Name:         "delete",
@@ -379,12 +687,335 @@ func main() {
 			},
 		},
 
+		// run command with ephemeral ssh-agent
+		{
+			Name:        "exec",
+			Description: "Run command with private ssh-agent holding only selected ssh-keys",
+			Usage:       "run command with ephemeral ssh-agent holding selected ssh-keys",
+			UsageText: "`gopass-ssh-add --store=ssh-keys exec --key path/to/ssh/key -- git push`" +
+				"\n\n" +
+				"`gopass-ssh-add --store=ssh-keys exec -k path/to/ssh/key1 -k path/to/ssh/key2 -- ssh host`",
+			Aliases: []string{"run"},
+			Hidden:  false,
+			Action:  gc.Exec,
+			Before:  gc.BeforeBase,
+			Flags: []cli.Flag{
+				&cli.StringSliceFlag{
+					Name:    "key",
+					Aliases: []string{"k"},
+					Usage:   "ssh-key path to add to ephemeral ssh-agent (can be repeated)",
+				},
+				&cli.IntFlag{
+					Name:    "lifetime",
+					Value:   0,
+					Aliases: []string{"time", "t"},
+					Usage:   "set a maximum lifetime when adding identities to an agent.",
+				},
+			},
+		},
+
+		// ssh signatures
+		{
+			Name:        "sign",
+			Description: "Sign data with ssh-key saved in gopass (SSHSIG format, same as `ssh-keygen -Y sign`)",
+			Usage:       "sign data with ssh-key saved in gopass",
+			UsageText: "`gopass-ssh-add --store=ssh-keys sign --key path/to/ssh/key --namespace file release.tar.gz` # write release.tar.gz.sig" +
+				"\n\n" +
+				"`echo data | gopass-ssh-add --store=ssh-keys sign --key path/to/ssh/key --namespace file` # write signature to stdout",
+			Aliases: []string{},
+			Hidden:  false,
+			Action:  gc.Sign,
+			Before:  gc.BeforeBase,
+			Flags: []cli.Flag{
+				&cli.StringFlag{
+					Name:     "key",
+					Aliases:  []string{"k"},
+					Usage:    "ssh-key path used for signing",
+					Required: true,
+				},
+				appNamespaceFlag,
+			},
+		},
+		{
+			Name:        "verify",
+			Description: "Verify SSHSIG signature using allowed signers file (same as `ssh-keygen -Y verify`)",
+			Usage:       "verify ssh signature using allowed signers file",
+			UsageText: "`gopass-ssh-add verify --allowed-signers ./allowed_signers --namespace file release.tar.gz` # verify release.tar.gz.sig" +
+				"\n\n" +
+				"`cat data | gopass-ssh-add verify --allowed-signers ./allowed_signers --identity user@example.com --signature data.sig`",
+			Aliases: []string{},
+			Hidden:  false,
+			Action:  gc.Verify,
+			Before:  gc.BeforeBase,
+			Flags: []cli.Flag{
+				&cli.StringFlag{
+					Name:     "allowed-signers",
+					Aliases:  []string{"f"},
+					Usage:    "allowed signers file path",
+					Required: true,
+				},
+				&cli.StringFlag{
+					Name:    "identity",
+					Aliases: []string{"I"},
+					Usage:   "signer identity (principal), any principal of signing key if empty",
+				},
+				&cli.StringFlag{
+					Name:    "signature",
+					Aliases: []string{"s"},
+					Usage:   "signature file path (default: <file>.sig)",
+				},
+				appNamespaceFlag,
+			},
+		},
+
+		// allowed signers
+		{
+			Name:        "allowed-signers",
+			Description: "Manage allowed_signers files for ssh signatures verification",
+			Usage:       "manage allowed_signers files",
+			Hidden:      false,
+			Aliases:     []string{},
+			Subcommands: []*cli.Command{
+				{
+					Name: "build",
+					Description: "Generate allowed_signers file from public ssh-keys saved in gopass. " +
+						"Principals are taken from `principals` (or `email`) metadata, namespaces from `namespaces`, " +
+						"validity period from `created` and `expires`",
+					Usage: "generate allowed_signers file from public ssh-keys saved in gopass",
+					UsageText: "`gopass-ssh-add --store=ssh-keys allowed-signers build --prefix team/ > allowed_signers`" +
+						"\n\n" +
+						"`gopass-ssh-add --store=ssh-keys allowed-signers build --prefix team/ --file ~/.ssh/allowed_signers`",
+					Aliases: []string{"generate", "gen"},
+					Hidden:  false,
+					Action:  gc.AllowedSignersBuild,
+					Before:  gc.BeforeBase,
+					Flags: []cli.Flag{
+						&cli.StringFlag{
+							Name:  "prefix",
+							Value: "",
+							Usage: "ssh-key path prefix",
+						},
+						&cli.StringFlag{
+							Name:    "file",
+							Value:   "-",
+							Aliases: []string{"f"},
+							Usage:   "allowed signers file path (\"-\" for stdout)",
+						},
+						&cli.BoolFlag{
+							Name:  "validity",
+							Value: true,
+							Usage: "add valid-after/valid-before options from ssh-key creation and expiry dates",
+						},
+					},
+				},
+			},
+		},
+
+		// deploy public ssh-key
+		{
+			Name:        "deploy",
+			Description: "Add public ssh-key to remote host authorized_keys using keys from ssh-agent for authentication (like ssh-copy-id)",
+			Usage:       "add public ssh-key to remote host authorized_keys",
+			UsageText: "`gopass-ssh-add --store=ssh-keys deploy path/to/ssh/key user@host`" +
+				"\n\n" +
+				"`gopass-ssh-add --store=ssh-keys deploy --revoke path/to/ssh/key user@host:2222` # remove public ssh-key from remote host",
+			Aliases:      []string{"copy-id"},
+			Hidden:       false,
+			Action:       gc.Deploy,
+			Before:       gc.Before,
+			BashComplete: gc.PathAutocomplete,
+			Flags: []cli.Flag{
+				&cli.BoolFlag{
+					Name:  "revoke",
+					Value: false,
+					Usage: "remove public ssh-key from remote authorized_keys",
+				},
+				&cli.IntFlag{
+					Name:    "port",
+					Value:   22,
+					Aliases: []string{"p"},
+					Usage:   "remote ssh port",
+				},
+				&cli.StringFlag{
+					Name:        "known-hosts",
+					Value:       defaultKnownHostsFile,
+					DefaultText: defaultKnownHostsFile,
+					Usage:       "known_hosts file used to verify remote host key",
+				},
+				&cli.BoolFlag{
+					Name:  "insecure",
+					Value: false,
+					Usage: "do not verify remote host key",
+				},
+			},
+		},
+
+		// manage known hosts
+		{
+			Name:        "known-hosts",
+			Aliases:     []string{"kh"},
+			Description: "Manage known_hosts entries saved in gopass",
+			Usage:       "manage known_hosts entries saved in gopass",
+			Hidden:      false,
+			Flags: []cli.Flag{
+				appKnownHostsPrefixFlag,
+			},
+			Subcommands: []*cli.Command{
+				{
+					Name:        "list",
+					Description: "Show known_hosts entries saved in gopass",
+					Usage:       "show known_hosts entries saved in gopass",
+					UsageText: "`gopass-ssh-add known-hosts list` # show all entries" +
+						"\n\n" +
+						"`gopass-ssh-add known-hosts list github.com` # show entries for host",
+					Aliases: []string{"ls", "show"},
+					Hidden:  false,
+					Action:  gc.KnownHostsList,
+					Before:  gc.BeforeBase,
+				},
+				{
+					Name:        "import",
+					Description: "Merge entries from local known_hosts file to gopass",
+					Usage:       "merge entries from local known_hosts file to gopass",
+					UsageText: "`gopass-ssh-add known-hosts import --file ~/.ssh/known_hosts`" +
+						"\n\n" +
+						"`ssh-keyscan github.com | gopass-ssh-add known-hosts import --hash --file -` # import from stdin with hashed hostnames",
+					Aliases: []string{"merge"},
+					Hidden:  false,
+					Action:  gc.KnownHostsImport,
+					Before:  gc.BeforeBase,
+					Flags: []cli.Flag{
+						appKnownHostsFileFlag,
+						appKnownHostsHashFlag,
+						&cli.BoolFlag{
+							Name:  "force",
+							Value: false,
+							Usage: "replace conflicting host keys saved in gopass",
+						},
+					},
+				},
+				{
+					Name:        "export",
+					Description: "Write consolidated known_hosts file from gopass",
+					Usage:       "write consolidated known_hosts file from gopass",
+					UsageText: "`gopass-ssh-add known-hosts export --file ~/.ssh/known_hosts`" +
+						"\n\n" +
+						"`gopass-ssh-add known-hosts export --file -` # write to stdout",
+					Aliases: []string{},
+					Hidden:  false,
+					Action:  gc.KnownHostsExport,
+					Before:  gc.BeforeBase,
+					Flags: []cli.Flag{
+						appKnownHostsFileFlag,
+						appKnownHostsHashFlag,
+					},
+				},
+				{
+					Name:        "delete",
+					Description: "Delete host entries from gopass",
+					Usage:       "delete host entries from gopass",
+					UsageText:   "`gopass-ssh-add known-hosts delete github.com`",
+					Aliases:     []string{"remove", "del", "rm"},
+					Hidden:      false,
+					Action:      gc.KnownHostsDelete,
+					Before:      gc.BeforeBase,
+				},
+				{
+					Name:        "conflicts",
+					Description: "Show conflicting host keys in gopass and local known_hosts file",
+					Usage:       "show conflicting host keys in gopass and local known_hosts file",
+					UsageText:   "`gopass-ssh-add known-hosts conflicts --file ~/.ssh/known_hosts`",
+					Aliases:     []string{"check"},
+					Hidden:      false,
+					Action:      gc.KnownHostsConflicts,
+					Before:      gc.BeforeBase,
+					Flags: []cli.Flag{
+						appKnownHostsFileFlag,
+					},
+				},
+			},
+		},
+
+		// ssh-keys expiry
+		{
+			Name:        "expiring",
+			Description: "Show ssh-keys which expire within period (by `expires` metadata) or already expired",
+			Usage:       "show ssh-keys which need rotation soon",
+			UsageText: "`gopass-ssh-add --store=ssh-keys expiring --within 14d`" +
+				"\n\n" +
+				"`gopass-ssh-add --store=ssh-keys secret generate --expires 90d path/to/ssh/key` # set expiry on generate",
+			Aliases: []string{},
+			Hidden:  false,
+			Action:  gc.Expiring,
+			Before:  gc.BeforeBase,
+			Flags: []cli.Flag{
+				&cli.StringFlag{
+					Name:  "within",
+					Value: "14d",
+					Usage: "period (e.g. 14d, 2w, 12h)",
+				},
+			},
+		},
+
+		// policy
+		{
+			Name:        "policy",
+			Description: "Check ssh-keys against policy file (allowed key types and sizes, passphrase length and entropy, comment pattern, expiry)",
+			Usage:       "check ssh-keys against policy file",
+			Aliases:     []string{},
+			Hidden:      false,
+			Subcommands: []*cli.Command{
+				{
+					Name:        "check",
+					Description: "Check stored ssh-keys against policy file (all ssh-keys in store if path is not set), exit with non-zero code if violations are found",
+					Usage:       "check stored ssh-keys against policy file",
+					UsageText: "`gopass-ssh-add --store=ssh-keys policy check`" +
+						"\n\n" +
+						"`gopass-ssh-add --policy ./policy.yaml policy check path/to/ssh/key`",
+					Aliases:      []string{},
+					Hidden:       false,
+					Action:       gc.PolicyCheck,
+					Before:       gc.BeforeBase,
+					BashComplete: gc.PathAutocomplete,
+				},
+			},
+		},
+
+		// configuration
+		{
+			Name:        "config",
+			Description: "Manage gopass-ssh-add configuration",
+			Usage:       "manage gopass-ssh-add configuration",
+			Aliases:     []string{},
+			Hidden:      false,
+			Subcommands: []*cli.Command{
+				{
+					Name:        "show",
+					Description: "Show effective configuration merged from config files and environment variables (with overrides for ssh-key path if it is set)",
+					Usage:       "show effective configuration",
+					UsageText: "`gopass-ssh-add config show`" +
+						"\n\n" +
+						"`gopass-ssh-add config show prod/ssh/key` # with prefix overrides for ssh-key",
+					Aliases:      []string{"print"},
+					Hidden:       false,
+					Action:       gc.ConfigShow,
+					Before:       gc.BeforeBase,
+					BashComplete: gc.PathAutocomplete,
+				},
+			},
+		},
+
 		// show version
 		{
-			Name: "version",
+			Name:   "version",
+			Before: gc.BeforeBase,
 			Action: func(c *cli.Context) error {
-				cli.VersionPrinter(c)
-				return nil
+				if !gc.structured() {
+					cli.VersionPrinter(c)
+					return nil
+				}
+
+				return gc.printResult(map[string]string{"version": c.App.Version}, "")
 			},
 		},
 
@@ -395,7 +1026,7 @@ func main() {
 			Action: func(c *cli.Context) error {
 				doc, err := app.ToMarkdown()
 				if err != nil {
-					logger.Fatalf("Cannot generate readme: %s", err.Error())
+					gc.logger.Fatalf("Cannot generate readme: %s", err.Error())
 				}
 
 				filePath := c.Args().Get(0)
@@ -405,12 +1036,12 @@ func main() {
 
 				absFilePath, err := filepath.Abs(filePath)
 				if err != nil {
-					logger.Fatalf("Cannot get abs path of readme file: %s", err.Error())
+					gc.logger.Fatalf("Cannot get abs path of readme file: %s", err.Error())
 				}
 
 				err = os.WriteFile(absFilePath, []byte(doc), 0644)
 				if err != nil {
-					logger.Fatalf("Cannot write docs to readme file: %s", err.Error())
+					gc.logger.Fatalf("Cannot write docs to readme file: %s", err.Error())
 				}
 
 				return nil
@@ -418,7 +1049,5 @@ func main() {
 		},
 	}
 
-	if err := app.RunContext(ctx, os.Args); err != nil {
-		log.Fatal(err)
-	}
+	return app
 }
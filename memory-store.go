@@ -0,0 +1,210 @@
+package main
+
+import (
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// memoryKeyStore is in-memory storage backend, it is used in tests
+type memoryKeyStore struct {
+	passwords   map[string]string
+	privateKeys map[string][]byte
+	publicKeys  map[string][]byte
+	metadata    map[string]map[string]string
+	knownHosts  map[string]map[string][]knownHostEntry
+
+	keysChanged bool
+}
+
+func newMemoryKeyStore() *memoryKeyStore {
+	return &memoryKeyStore{
+		passwords:   make(map[string]string),
+		privateKeys: make(map[string][]byte),
+		publicKeys:  make(map[string][]byte),
+		metadata:    make(map[string]map[string]string),
+		knownHosts:  make(map[string]map[string][]knownHostEntry),
+	}
+}
+
+func (ms *memoryKeyStore) clone() *memoryKeyStore {
+	var o = newMemoryKeyStore()
+	o.keysChanged = ms.keysChanged
+	for k, v := range ms.passwords {
+		o.passwords[k] = v
+	}
+	for k, v := range ms.privateKeys {
+		o.privateKeys[k] = v
+	}
+	for k, v := range ms.publicKeys {
+		o.publicKeys[k] = v
+	}
+	for k, meta := range ms.metadata {
+		o.metadata[k] = make(map[string]string)
+		for mk, mv := range meta {
+			o.metadata[k][mk] = mv
+		}
+	}
+	for prefix, entries := range ms.knownHosts {
+		o.knownHosts[prefix] = make(map[string][]knownHostEntry)
+		for id, e := range entries {
+			o.knownHosts[prefix][id] = e
+		}
+	}
+	return o
+}
+
+func (ms *memoryKeyStore) list(prefix string) (ll []string, err error) {
+	var keys []string
+	for k := range ms.passwords {
+		keys = append(keys, k)
+	}
+	for k := range ms.privateKeys {
+		keys = append(keys, k)
+	}
+	for k := range ms.publicKeys {
+		keys = append(keys, k)
+	}
+
+	var m = make(map[string]bool)
+	for _, k := range keys {
+		if prefix != "" && !strings.HasPrefix(k, prefix+"/") {
+			continue
+		}
+
+		var p = strings.TrimPrefix(k, prefix+"/")
+		if !m[p] {
+			m[p] = true
+			ll = append(ll, p)
+		}
+	}
+
+	sort.Strings(ll)
+	return
+}
+
+func (ms *memoryKeyStore) getPassword(key string) (string, error) {
+	p, ok := ms.passwords[key]
+	if !ok {
+		return "", ErrNotFound
+	}
+	return p, nil
+}
+
+func (ms *memoryKeyStore) setPassword(key, password string) error {
+	ms.keysChanged = true
+	ms.passwords[key] = password
+	return nil
+}
+
+func (ms *memoryKeyStore) delPassword(key string) error {
+	ms.keysChanged = true
+	delete(ms.passwords, key)
+	return nil
+}
+
+func (ms *memoryKeyStore) getPrivateSSHKey(key string) ([]byte, error) {
+	data, ok := ms.privateKeys[key]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return data, nil
+}
+
+func (ms *memoryKeyStore) setPrivateSSHKey(key string, data []byte) error {
+	ms.keysChanged = true
+	ms.privateKeys[key] = data
+	return nil
+}
+
+func (ms *memoryKeyStore) delPrivateSSHKey(key string) error {
+	ms.keysChanged = true
+	delete(ms.privateKeys, key)
+	return nil
+}
+
+func (ms *memoryKeyStore) getPublicSSHKey(key string) ([]byte, error) {
+	data, ok := ms.publicKeys[key]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return data, nil
+}
+
+func (ms *memoryKeyStore) setPublicSSHKey(key string, data []byte) error {
+	ms.keysChanged = true
+	ms.publicKeys[key] = data
+	return nil
+}
+
+func (ms *memoryKeyStore) delPublicSSHKey(key string) error {
+	ms.keysChanged = true
+	delete(ms.publicKeys, key)
+	delete(ms.metadata, key)
+	return nil
+}
+
+func (ms *memoryKeyStore) getMetadata(key string) (map[string]string, error) {
+	var o = make(map[string]string)
+	for k, v := range ms.metadata[key] {
+		o[k] = v
+	}
+	return o, nil
+}
+
+func (ms *memoryKeyStore) setMetadata(key string, meta map[string]string) error {
+	if _, ok := ms.publicKeys[key]; !ok {
+		return ErrNotFound
+	}
+
+	if ms.metadata[key] == nil {
+		ms.metadata[key] = make(map[string]string)
+	}
+
+	for k, v := range meta {
+		if v == "" {
+			delete(ms.metadata[key], k)
+			continue
+		}
+		ms.metadata[key][k] = v
+	}
+	return nil
+}
+
+func (ms *memoryKeyStore) getKnownHosts(prefix string) (map[string][]knownHostEntry, error) {
+	var o = make(map[string][]knownHostEntry)
+	for id, entries := range ms.knownHosts[prefix] {
+		o[id] = append([]knownHostEntry(nil), entries...)
+	}
+	return o, nil
+}
+
+func (ms *memoryKeyStore) setKnownHosts(prefix, id string, entries []knownHostEntry) error {
+	if len(entries) == 0 {
+		delete(ms.knownHosts[prefix], id)
+		return nil
+	}
+
+	if ms.knownHosts[prefix] == nil {
+		ms.knownHosts[prefix] = make(map[string][]knownHostEntry)
+	}
+	ms.knownHosts[prefix][id] = entries
+	return nil
+}
+
+func (ms *memoryKeyStore) transaction(fn func() error) error {
+	var backup = ms.clone()
+	err := fn()
+	if err != nil {
+		*ms = *backup
+	}
+	return err
+}
+
+func (ms *memoryKeyStore) changed() bool {
+	return ms.keysChanged
+}
+
+func (ms *memoryKeyStore) partName(key, part string) (string, error) {
+	return filepath.Join(key, part), nil
+}
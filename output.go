@@ -0,0 +1,161 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+	"gopkg.in/yaml.v3"
+)
+
+// command output formats
+const (
+	appOutputText = "text"
+	appOutputJSON = "json"
+	appOutputYAML = "yaml"
+)
+
+var appOutputs = []string{appOutputText, appOutputJSON, appOutputYAML}
+
+// log formats, logs are always written to stderr
+const (
+	appLogFormatText = "text"
+	appLogFormatJSON = "json"
+)
+
+// error codes of structured output
+const (
+	errCodeGeneric         = "error"
+	errCodeNotFound        = "not_found"
+	errCodeInvalidArgument = "invalid_argument"
+	errCodeVerification    = "verification_failed"
+	errCodeCancelled       = "cancelled"
+	errCodeAuditFailed     = "audit_failed"
+	errCodePolicyViolation = "policy_violation"
+	errCodeExpired         = "expired"
+)
+
+// appError is error with stable code
+type appError struct {
+	Code    string `json:"code" yaml:"code"`
+	Message string `json:"message" yaml:"message"`
+	err     error
+}
+
+func newAppError(code string, err error) *appError {
+	return &appError{
+		Code:    code,
+		Message: err.Error(),
+		err:     err,
+	}
+}
+
+func (e *appError) Error() string {
+	return e.Message
+}
+
+func (e *appError) Unwrap() error {
+	return e.err
+}
+
+var errCancelled = newAppError(errCodeCancelled, errors.New("cancelled"))
+
+// newUsageError returns invalid argument error
+func newUsageError(format string, a ...any) *appError {
+	return newAppError(errCodeInvalidArgument, fmt.Errorf(format, a...))
+}
+
+// getAppError returns error with code, gopass not found error gets not_found code
+func getAppError(err error) *appError {
+	var ae *appError
+	if errors.As(err, &ae) {
+		return ae
+	}
+
+	if strings.Contains(err.Error(), ErrNotFound.Error()) {
+		return newAppError(errCodeNotFound, err)
+	}
+	return newAppError(errCodeGeneric, err)
+}
+
+func checkAppOutput(output string) error {
+	for _, o := range appOutputs {
+		if o == output {
+			return nil
+		}
+	}
+	return newUsageError("unknown output format '%s' (%s)", output, strings.Join(appOutputs, ", "))
+}
+
+// sshKeyResult is structured result of command working with one ssh-key
+type sshKeyResult struct {
+	Key         string            `json:"key" yaml:"key"`
+	Action      string            `json:"action,omitempty" yaml:"action,omitempty"`
+	Password    string            `json:"password,omitempty" yaml:"password,omitempty"`
+	Entropy     float64           `json:"entropy,omitempty" yaml:"entropy,omitempty"`
+	PrivateKey  string            `json:"private_key,omitempty" yaml:"private_key,omitempty"`
+	PublicKey   string            `json:"public_key,omitempty" yaml:"public_key,omitempty"`
+	Fingerprint string            `json:"fingerprint,omitempty" yaml:"fingerprint,omitempty"`
+	Lifetime    int               `json:"lifetime,omitempty" yaml:"lifetime,omitempty"`
+	Metadata    map[string]string `json:"metadata,omitempty" yaml:"metadata,omitempty"`
+}
+
+// sshAgentKeyResult is structured result of ssh-key added to ssh-agent
+type sshAgentKeyResult struct {
+	Type        string `json:"type" yaml:"type"`
+	Fingerprint string `json:"fingerprint" yaml:"fingerprint"`
+	Comment     string `json:"comment" yaml:"comment"`
+	PublicKey   string `json:"public_key" yaml:"public_key"`
+}
+
+func newSSHAgentKeyResult(key *agent.Key) sshAgentKeyResult {
+	return sshAgentKeyResult{
+		Type:        key.Type(),
+		Fingerprint: ssh.FingerprintSHA256(key),
+		Comment:     key.Comment,
+		PublicKey:   strings.TrimSpace(string(ssh.MarshalAuthorizedKey(key))),
+	}
+}
+
+// structured checks command result is printed as json or yaml
+func (s gc) structured() bool {
+	return s.output == appOutputJSON || s.output == appOutputYAML
+}
+
+// printResult prints command result as json or yaml, text is printed as is in text output mode
+func (s gc) printResult(v any, text string) error {
+	if !s.structured() {
+		_, err := fmt.Fprint(s.stdout, text)
+		return err
+	}
+	return encodeOutput(s.stdout, s.output, v)
+}
+
+// printError prints structured error to stderr, so stdout contains only command result
+func (s gc) printError(err error) error {
+	return encodeOutput(os.Stderr, s.output, struct {
+		Error *appError `json:"error" yaml:"error"`
+	}{getAppError(err)})
+}
+
+// encodeOutput writes value as yaml or indented json
+func encodeOutput(w io.Writer, output string, v any) error {
+	if output == appOutputYAML {
+		enc := yaml.NewEncoder(w)
+		enc.SetIndent(2)
+		err := enc.Encode(v)
+		if err != nil {
+			return err
+		}
+		return enc.Close()
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
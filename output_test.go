@@ -0,0 +1,35 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetAppError(t *testing.T) {
+	assert.Equal(t, errCodeGeneric, getAppError(errors.New("failed")).Code)
+	assert.Equal(t, errCodeNotFound, getAppError(fmt.Errorf("getting key: %s", ErrNotFound)).Code)
+	assert.Equal(t, errCodeInvalidArgument, getAppError(newUsageError("path must be set")).Code)
+
+	var err = fmt.Errorf("wrapped: %w", errCancelled)
+	assert.True(t, errors.Is(err, errCancelled))
+	assert.Equal(t, errCodeCancelled, getAppError(err).Code)
+}
+
+func TestEncodeOutput(t *testing.T) {
+	var res = sshKeyResult{Key: "ssh-keys/test", Action: "deleted"}
+
+	var buf bytes.Buffer
+	assert.Nil(t, encodeOutput(&buf, appOutputJSON, res))
+	assert.Equal(t, "{\n  \"key\": \"ssh-keys/test\",\n  \"action\": \"deleted\"\n}\n", buf.String())
+
+	buf.Reset()
+	assert.Nil(t, encodeOutput(&buf, appOutputYAML, res))
+	assert.Equal(t, "key: ssh-keys/test\naction: deleted\n", buf.String())
+
+	assert.Nil(t, checkAppOutput(appOutputYAML))
+	assert.NotNil(t, checkAppOutput("xml"))
+}
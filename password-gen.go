@@ -0,0 +1,175 @@
+package main
+
+import (
+	"crypto/rand"
+	_ "embed"
+	"fmt"
+	"math"
+	"math/big"
+	"strings"
+
+	"github.com/sethvargo/go-password/password"
+	"github.com/urfave/cli/v2"
+)
+
+// password generation modes
+const (
+	passwordModeRandom = "random"
+	passwordModeWords  = "words"
+	passwordModePin    = "pin"
+)
+
+var passwordModes = []string{passwordModeRandom, passwordModeWords, passwordModePin}
+
+// minimal password sizes by mode, length for random and pin modes, word count for words mode
+const (
+	passwordMinLength = 16
+	passwordMinPin    = 4
+	passwordMinWords  = 3
+)
+
+// wordlist is 2048 english words of BIP-0039 mnemonic, every word adds 11 bits of entropy
+//
+//go:embed wordlist.txt
+var wordlistData string
+
+var wordlist = strings.Fields(wordlistData)
+
+// passwordOptions are password generation settings, negative digits or symbols count means 1/4 of length
+type passwordOptions struct {
+	Mode      string
+	Length    int
+	Digits    int
+	Symbols   int
+	SymbolSet string
+	Words     int
+	Separator string
+}
+
+// getPasswordOptions returns password generation settings from command flags
+func getPasswordOptions(c *cli.Context) passwordOptions {
+	var o = passwordOptions{
+		Mode:      c.String("mode"),
+		Length:    c.Int("length"),
+		Digits:    c.Int("digits"),
+		Symbols:   c.Int("symbols-count"),
+		SymbolSet: c.String("symbol-set"),
+		Words:     c.Int("words"),
+		Separator: c.String("separator"),
+	}
+
+	if o.Digits < 0 {
+		o.Digits = o.Length / 4
+	}
+	if o.Symbols < 0 {
+		o.Symbols = o.Length / 4
+	}
+	if !c.Bool("symbols") {
+		o.Symbols = 0
+	}
+	return o
+}
+
+// randomInt returns uniform random number in [0, max)
+func randomInt(max int) (int, error) {
+	n, err := rand.Int(rand.Reader, big.NewInt(int64(max)))
+	if err != nil {
+		return 0, err
+	}
+	return int(n.Int64()), nil
+}
+
+func genPassword(o passwordOptions) (out string, err error) {
+	switch o.Mode {
+	case passwordModeRandom:
+		if o.Length < passwordMinLength {
+			return "", fmt.Errorf("password is too short (minimal length is %d)", passwordMinLength)
+		}
+		if o.Digits+o.Symbols > o.Length {
+			return "", newUsageError("digits and symbols count (%d) exceeds password length (%d)", o.Digits+o.Symbols, o.Length)
+		}
+		if o.Symbols > 0 && o.SymbolSet == "" {
+			return "", newUsageError("symbol set is empty")
+		}
+
+		g, err := password.NewGenerator(&password.GeneratorInput{Symbols: o.SymbolSet})
+		if err != nil {
+			return "", err
+		}
+		return g.Generate(o.Length, o.Digits, o.Symbols, true, true)
+	case passwordModePin:
+		if o.Length < passwordMinPin {
+			return "", fmt.Errorf("pin is too short (minimal length is %d)", passwordMinPin)
+		}
+
+		var sb strings.Builder
+		for i := 0; i < o.Length; i++ {
+			n, err := randomInt(len(password.Digits))
+			if err != nil {
+				return "", err
+			}
+			sb.WriteByte(password.Digits[n])
+		}
+		return sb.String(), nil
+	case passwordModeWords:
+		if o.Words < passwordMinWords {
+			return "", fmt.Errorf("passphrase is too short (minimal word count is %d)", passwordMinWords)
+		}
+
+		var words = make([]string, o.Words)
+		for i := range words {
+			n, err := randomInt(len(wordlist))
+			if err != nil {
+				return "", err
+			}
+			words[i] = wordlist[n]
+		}
+		return strings.Join(words, o.Separator), nil
+	default:
+		return "", newUsageError("unknown password mode '%s' (%s)", o.Mode, strings.Join(passwordModes, ", "))
+	}
+}
+
+// passwordEntropy returns entropy estimate of generated password in bits
+func passwordEntropy(o passwordOptions) float64 {
+	switch o.Mode {
+	case passwordModeRandom:
+		var letters = o.Length - o.Digits - o.Symbols
+		var bits = float64(letters)*math.Log2(float64(len(password.LowerLetters))) +
+			float64(o.Digits)*math.Log2(float64(len(password.Digits)))
+		if o.Symbols > 0 {
+			bits += float64(o.Symbols) * math.Log2(float64(len(o.SymbolSet)))
+		}
+
+		// positions of digits and symbols between letters
+		return bits + log2Factorial(o.Length) - log2Factorial(letters) - log2Factorial(o.Digits) - log2Factorial(o.Symbols)
+	case passwordModePin:
+		return float64(o.Length) * math.Log2(float64(len(password.Digits)))
+	case passwordModeWords:
+		return float64(o.Words) * math.Log2(float64(len(wordlist)))
+	}
+	return 0
+}
+
+func log2Factorial(n int) (out float64) {
+	for i := 2; i <= n; i++ {
+		out += math.Log2(float64(i))
+	}
+	return
+}
+
+// generatePassword generates password and logs its entropy estimate
+func (s *gc) generatePassword(o passwordOptions) (string, float64, error) {
+	s.log().Infof("generating %s password", o.Mode)
+	passwd, err := genPassword(o)
+	if err != nil {
+		return "", 0, err
+	}
+
+	var entropy = math.Round(passwordEntropy(o)*10) / 10
+	s.log().Infof("password entropy is about %.1f bits", entropy)
+	if entropy < 64 {
+		s.log().Warnf("password entropy is low (%.1f bits)", entropy)
+	}
+	return passwd, entropy, nil
+}
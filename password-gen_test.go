@@ -0,0 +1,43 @@
+package main
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGenPassword(t *testing.T) {
+	var o = passwordOptions{Mode: passwordModeRandom, Length: 20, Digits: 5, Symbols: 3, SymbolSet: "#%"}
+	passwd, err := genPassword(o)
+	assert.Nil(t, err)
+	assert.Len(t, passwd, 20)
+	assert.Equal(t, 3, strings.Count(passwd, "#")+strings.Count(passwd, "%"))
+	assert.Len(t, regexp.MustCompile("[0-9]").FindAllString(passwd, -1), 5)
+
+	o.Digits = 20
+	_, err = genPassword(o)
+	assert.Equal(t, errCodeInvalidArgument, getAppError(err).Code)
+
+	_, err = genPassword(passwordOptions{Mode: passwordModeRandom, Length: passwordMinLength - 1})
+	assert.ErrorContains(t, err, "password is too short")
+
+	passwd, err = genPassword(passwordOptions{Mode: passwordModePin, Length: 6})
+	assert.Nil(t, err)
+	assert.Regexp(t, "^[0-9]{6}$", passwd)
+
+	passwd, err = genPassword(passwordOptions{Mode: passwordModeWords, Words: 4, Separator: " "})
+	assert.Nil(t, err)
+	assert.Len(t, strings.Split(passwd, " "), 4)
+
+	_, err = genPassword(passwordOptions{Mode: passwordModeWords, Words: 2})
+	assert.NotNil(t, err)
+}
+
+func TestPasswordEntropy(t *testing.T) {
+	assert.Len(t, wordlist, 2048)
+	assert.Equal(t, 66.0, passwordEntropy(passwordOptions{Mode: passwordModeWords, Words: 6}))
+	assert.InDelta(t, 19.93, passwordEntropy(passwordOptions{Mode: passwordModePin, Length: 6}), 0.01)
+	assert.InDelta(t, 47.0, passwordEntropy(passwordOptions{Mode: passwordModeRandom, Length: 10}), 0.01)
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/twpayne/go-pinentry"
+	"golang.org/x/term"
+)
+
+// password input modes
+const (
+	passwordInputAuto     = "auto"
+	passwordInputPinentry = "pinentry"
+	passwordInputTerminal = "terminal"
+	passwordInputStdin    = "stdin"
+)
+
+var passwordInputs = []string{passwordInputAuto, passwordInputPinentry, passwordInputTerminal, passwordInputStdin}
+
+var errPasswordMismatch = errors.New("passwords do not match")
+
+// trimPasswordNewline removes one trailing line break added by `echo` or terminal
+func trimPasswordNewline(data []byte) string {
+	var out = strings.TrimSuffix(string(data), "\n")
+	return strings.TrimSuffix(out, "\r")
+}
+
+// readPasswordFromStdIn returns password from stdin without trailing line break
+func readPasswordFromStdIn() (string, error) {
+	data, err := getDataFromStdIn()
+	if err != nil {
+		return "", err
+	}
+
+	var out = trimPasswordNewline(data)
+	if out == "" {
+		return "", errors.New("password is empty")
+	}
+	return out, nil
+}
+
+// getPasswordInput resolves auto input mode: stdin if it is not terminal, pinentry if program is found, terminal prompt otherwise
+func getPasswordInput(input, pinentryProgram string) (string, error) {
+	switch input {
+	case passwordInputPinentry, passwordInputTerminal, passwordInputStdin:
+		return input, nil
+	case passwordInputAuto:
+	default:
+		return "", newUsageError("unknown password input '%s' (%s)", input, strings.Join(passwordInputs, ", "))
+	}
+
+	if !isTerminal() {
+		return passwordInputStdin, nil
+	}
+
+	if pinentryProgram != "" {
+		if _, err := exec.LookPath(pinentryProgram); err == nil {
+			return passwordInputPinentry, nil
+		}
+	}
+	return passwordInputTerminal, nil
+}
+
+// readPassword reads password using input mode, interactive inputs ask password twice
+func (s *gc) readPassword(input, pinentryProgram, desc string) (string, error) {
+	input, err := getPasswordInput(input, pinentryProgram)
+	if err != nil {
+		return "", err
+	}
+
+	var prompt func(prompt string) (string, error)
+	switch input {
+	case passwordInputStdin:
+		s.log().Info("getting password from stdin")
+		return readPasswordFromStdIn()
+	case passwordInputPinentry:
+		s.log().Infof("getting password from %s", pinentryProgram)
+		prompt = func(prompt string) (string, error) {
+			return promptPinentry(pinentryProgram, desc, prompt)
+		}
+	default:
+		s.log().Info("getting password from terminal")
+		prompt = promptTerminal
+	}
+
+	password, err := prompt("Password:")
+	if err != nil {
+		return "", err
+	}
+	if password == "" {
+		return "", errors.New("password is empty")
+	}
+
+	repeated, err := prompt("Repeat password:")
+	if err != nil {
+		return "", err
+	}
+	if password != repeated {
+		return "", errPasswordMismatch
+	}
+	return password, nil
+}
+
+// promptPinentry asks password with pinentry program
+func promptPinentry(program, desc, prompt string) (string, error) {
+	client, err := pinentry.NewClient(
+		pinentry.WithBinaryName(program),
+		pinentry.WithGPGTTY(),
+		pinentry.WithTitle(appName),
+		pinentry.WithDesc(desc),
+		pinentry.WithPrompt(prompt),
+	)
+	if err != nil {
+		return "", fmt.Errorf("cannot start pinentry: %w", err)
+	}
+	defer client.Close()
+
+	pin, _, err := client.GetPIN()
+	if pinentry.IsCancelled(err) {
+		return "", errCancelled
+	}
+	return pin, err
+}
+
+// promptTerminal asks password on stderr without echo
+func promptTerminal(prompt string) (string, error) {
+	fmt.Fprintf(os.Stderr, "%s ", prompt)
+	data, err := term.ReadPassword(int(os.Stdin.Fd()))
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return "", fmt.Errorf("cannot read password from terminal: %w", err)
+	}
+	return string(data), nil
+}
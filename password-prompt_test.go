@@ -0,0 +1,23 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTrimPasswordNewline(t *testing.T) {
+	assert.Equal(t, "secret", trimPasswordNewline([]byte("secret\n")))
+	assert.Equal(t, "secret", trimPasswordNewline([]byte("secret\r\n")))
+	assert.Equal(t, "secret\n", trimPasswordNewline([]byte("secret\n\n")))
+	assert.Equal(t, " secret ", trimPasswordNewline([]byte(" secret ")))
+}
+
+func TestGetPasswordInput(t *testing.T) {
+	input, err := getPasswordInput(passwordInputTerminal, "pinentry")
+	assert.Nil(t, err)
+	assert.Equal(t, passwordInputTerminal, input)
+
+	_, err = getPasswordInput("gui", "pinentry")
+	assert.Equal(t, errCodeInvalidArgument, getAppError(err).Code)
+}
@@ -0,0 +1,281 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/nbutton23/zxcvbn-go"
+	"github.com/urfave/cli/v2"
+	"golang.org/x/crypto/ssh"
+	"gopkg.in/yaml.v3"
+)
+
+const appPolicyFilename = "policy.yaml"
+
+var appPolicyDefault = appConfigPath(appPolicyFilename)
+
+var policyViolationNoMetadata = "expiry date can not be stored: " + errMetadataNotSupported.Error()
+
+// appPolicy is organisation policy file applied on generate and insert, empty fields are not checked:
+//
+//	key-types: [ed25519, rsa]
+//	min-bits:
+//	  rsa: 3072
+//	min-password-length: 20
+//	min-password-entropy: 80
+//	comment-pattern: '^[a-z.]+@example\.com$'
+//	require-expiry: true
+//
+// expiry is checked on generate and by `policy check`, inserted ssh-keys get metadata later
+type appPolicy struct {
+	KeyTypes           []string       `json:"key-types,omitempty" yaml:"key-types,omitempty"`
+	MinBits            map[string]int `json:"min-bits,omitempty" yaml:"min-bits,omitempty"`
+	MinPasswordLength  int            `json:"min-password-length,omitempty" yaml:"min-password-length,omitempty"`
+	MinPasswordEntropy float64        `json:"min-password-entropy,omitempty" yaml:"min-password-entropy,omitempty"`
+	CommentPattern     string         `json:"comment-pattern,omitempty" yaml:"comment-pattern,omitempty"`
+	RequireExpiry      bool           `json:"require-expiry,omitempty" yaml:"require-expiry,omitempty"`
+
+	commentRe *regexp.Regexp
+}
+
+// policyResult is structured result of ssh-key policy check
+type policyResult struct {
+	Key        string   `json:"key" yaml:"key"`
+	Violations []string `json:"violations" yaml:"violations"`
+}
+
+// loadPolicy reads policy file, missing file means there is no policy
+func loadPolicy(path string) (p *appPolicy, err error) {
+	if path == "" {
+		return nil, nil
+	}
+
+	data, err := os.ReadFile(expandHomeDir(path))
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	p = &appPolicy{}
+	err = yaml.Unmarshal(data, p)
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse policy file '%s': %w", path, err)
+	}
+
+	if p.CommentPattern != "" {
+		p.commentRe, err = regexp.Compile(p.CommentPattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid comment pattern in policy file '%s': %w", path, err)
+		}
+	}
+	return p, nil
+}
+
+// getPolicy loads policy file set by `--policy` flag
+func (s *gc) getPolicy(c *cli.Context) (*appPolicy, error) {
+	return loadPolicy(c.String("policy"))
+}
+
+// newPolicyError returns error with all policy violations of ssh-key
+func newPolicyError(key string, violations []string) error {
+	return newAppError(errCodePolicyViolation,
+		fmt.Errorf("ssh-key '%s' violates policy: %s", key, strings.Join(violations, "; ")))
+}
+
+// getPublicSSHKeyComment returns public ssh-key comment, default comment of ssh-key path if it is missing
+func getPublicSSHKeyComment(pubKey []byte, key string) string {
+	var parts = strings.SplitN(string(getPublicSSHKeyWithComment(pubKey, key)), " ", 3)
+	return parts[len(parts)-1]
+}
+
+// checkKey returns violations of ssh-key type, size and comment
+func (p *appPolicy) checkKey(pubKey ssh.PublicKey, comment string) (o []string) {
+	if p == nil {
+		return nil
+	}
+
+	// lower case openssh type names (ed25519, rsa, ecdsa, ...)
+	var keyType = strings.ToLower(getSSHKeyTypeName(pubKey))
+	if len(p.KeyTypes) > 0 && !hasString(p.KeyTypes, keyType) {
+		o = append(o, fmt.Sprintf("ssh-key type %s is not allowed (%s)", keyType, strings.Join(p.KeyTypes, ", ")))
+	}
+
+	if minBits := p.MinBits[keyType]; minBits > 0 {
+		if bits := getSSHKeyBits(pubKey); bits < minBits {
+			o = append(o, fmt.Sprintf("ssh-key size %d is less than %d bits", bits, minBits))
+		}
+	}
+
+	if p.commentRe != nil && !p.commentRe.MatchString(comment) {
+		o = append(o, fmt.Sprintf("ssh-key comment '%s' does not match '%s'", comment, p.CommentPattern))
+	}
+	return
+}
+
+// checkPassword returns violations of passphrase length and entropy,
+// entropy is generation estimate or zxcvbn entropy if it is negative
+func (p *appPolicy) checkPassword(password string, entropy float64) (o []string) {
+	if p == nil {
+		return nil
+	}
+
+	if len(password) < p.MinPasswordLength {
+		o = append(o, fmt.Sprintf("passphrase length %d is less than %d", len(password), p.MinPasswordLength))
+	}
+
+	if p.MinPasswordEntropy > 0 {
+		if entropy < 0 {
+			entropy = zxcvbn.PasswordStrength(password, nil).Entropy
+		}
+		if entropy < p.MinPasswordEntropy {
+			o = append(o, fmt.Sprintf("passphrase entropy %.1f is less than %.1f bits", entropy, p.MinPasswordEntropy))
+		}
+	}
+	return
+}
+
+// requiresMetadata checks policy can not be met by ssh-key without metadata
+func (p *appPolicy) requiresMetadata() bool {
+	return p != nil && p.RequireExpiry
+}
+
+// checkMetadata returns violations of ssh-key metadata
+func (p *appPolicy) checkMetadata(meta map[string]string) (o []string) {
+	if p == nil || !p.RequireExpiry {
+		return nil
+	}
+
+	expires, ok := meta[gopassMetaExpires]
+	if !ok || expires == "" {
+		return []string{"expiry date is not set"}
+	}
+
+	t, err := parseMetadataTime(expires)
+	if err != nil {
+		return []string{fmt.Sprintf("invalid expiry date '%s'", expires)}
+	}
+	if t.Before(time.Now()) {
+		return []string{fmt.Sprintf("ssh-key expired at %s", expires)}
+	}
+	return nil
+}
+
+// getPrivateSSHKeyPublicKey returns public key of private ssh-key, encrypted key is decrypted with password
+func getPrivateSSHKeyPublicKey(data []byte, password string) (ssh.PublicKey, error) {
+	signer, err := ssh.ParsePrivateKey(data)
+	if err == nil {
+		return signer.PublicKey(), nil
+	}
+
+	var missingErr *ssh.PassphraseMissingError
+	if !errors.As(err, &missingErr) {
+		return nil, err
+	}
+	if missingErr.PublicKey != nil {
+		return missingErr.PublicKey, nil
+	}
+	if password == "" {
+		return nil, err
+	}
+
+	signer, err = ssh.ParsePrivateKeyWithPassphrase(data, []byte(password))
+	if err != nil {
+		return nil, err
+	}
+	return signer.PublicKey(), nil
+}
+
+// PolicyCheck - check stored ssh-keys against policy file
+func (s *gc) PolicyCheck(c *cli.Context) (err error) {
+	var store = c.String("store")
+
+	policy, err := s.getPolicy(c)
+	if err != nil {
+		return err
+	}
+	if policy == nil {
+		return newAppError(errCodeNotFound, fmt.Errorf("policy file '%s' is not found", c.String("policy")))
+	}
+
+	var paths = c.Args().Slice()
+	if len(paths) == 0 {
+		s.log().Info("getting ssh-keys list from gopass")
+		paths, err = s.ks.list(store)
+		if err != nil {
+			return err
+		}
+	}
+
+	var o = make([]policyResult, 0, len(paths))
+	var text strings.Builder
+	var failed int
+	for _, p := range paths {
+		var key = filepath.Join(store, p)
+		var log = s.log().WithField("gkey", key)
+		var res = policyResult{Key: key, Violations: []string{}}
+
+		log.Info("getting public ssh-key from gopass")
+		pubKeyB, err := s.ks.getPublicSSHKey(key)
+		switch {
+		case err == nil:
+			pubKey, _, _, _, err := ssh.ParseAuthorizedKey(pubKeyB)
+			if err != nil {
+				res.Violations = append(res.Violations, fmt.Sprintf("cannot parse public ssh-key: %s", err))
+				break
+			}
+			res.Violations = append(res.Violations, policy.checkKey(pubKey, getPublicSSHKeyComment(pubKeyB, key))...)
+		case err.Error() == ErrNotFound.Error():
+			res.Violations = append(res.Violations, "public ssh-key is missing")
+		default:
+			return err
+		}
+
+		log.Info("getting password from gopass")
+		password, err := s.ks.getPassword(key)
+		if err != nil && err.Error() != ErrNotFound.Error() {
+			return err
+		}
+		res.Violations = append(res.Violations, policy.checkPassword(password, -1)...)
+
+		meta, err := s.ks.getMetadata(key)
+		switch {
+		case errors.Is(err, errMetadataNotSupported):
+			if policy.requiresMetadata() {
+				res.Violations = append(res.Violations, policyViolationNoMetadata)
+			}
+		case err != nil:
+			return err
+		default:
+			res.Violations = append(res.Violations, policy.checkMetadata(meta)...)
+		}
+
+		if len(res.Violations) > 0 {
+			failed++
+			fmt.Fprintf(&text, "%s:\n", key)
+			for _, v := range res.Violations {
+				fmt.Fprintf(&text, "  - %s\n", v)
+			}
+		}
+		o = append(o, res)
+	}
+
+	err = s.printResult(o, text.String())
+	if err != nil {
+		return err
+	}
+
+	if failed > 0 {
+		return newAppError(errCodePolicyViolation, fmt.Errorf("%d of %d ssh-keys violate policy", failed, len(o)))
+	}
+
+	s.log().Infof("%d ssh-keys comply with policy", len(o))
+	return nil
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCommandsPolicy(t *testing.T) {
+	_, ms, run := newTestGc()
+
+	var policyFile = filepath.Join(t.TempDir(), appPolicyFilename)
+	assert.Nil(t, os.WriteFile(policyFile, []byte(""+
+		"key-types: [ed25519]\n"+
+		"min-password-entropy: 60\n"+
+		"comment-pattern: '@example\\.com$'\n"), 0600))
+
+	_, err := run("--policy", policyFile, "secret", "generate", "--mode", "words", "--words", "4", "--comment", "dev@example.com", "test/weak")
+	assert.Equal(t, errCodePolicyViolation, getAppError(err).Code)
+	assert.Contains(t, err.Error(), "passphrase entropy 44.0 is less than 60.0 bits")
+	assert.Empty(t, ms.privateKeys)
+
+	_, err = run("--policy", policyFile, "secret", "generate", "--type", "rsa", "--bits", "2048", "test/rsa")
+	assert.Equal(t, errCodePolicyViolation, getAppError(err).Code)
+	assert.Contains(t, err.Error(), "ssh-key type rsa is not allowed (ed25519)")
+	assert.Contains(t, err.Error(), "ssh-key comment 'gssh:ssh-keys/test/rsa' does not match '@example\\.com$'")
+
+	_, err = run("--policy", policyFile, "secret", "generate", "--comment", "dev@example.com", "test/key")
+	assert.Nil(t, err)
+
+	out, err := run("--policy", policyFile, "--output", "json", "policy", "check")
+	assert.Nil(t, err)
+
+	var res []policyResult
+	assert.Nil(t, json.Unmarshal([]byte(out), &res))
+	assert.Equal(t, []policyResult{{Key: "ssh-keys/test/key", Violations: []string{}}}, res)
+
+	assert.Nil(t, ms.setPassword("ssh-keys/test/key", "password"))
+	_, err = run("--policy", policyFile, "policy", "check", "test/key")
+	assert.Equal(t, errCodePolicyViolation, getAppError(err).Code)
+}
+
+func TestCommandsPolicyMetadataNotSupported(t *testing.T) {
+	s, _, run := newTestGc()
+	gs, store := newTestGopassStorage()
+	gs.mounts = map[string]gopassMount{"ssh-keys": {PublicKey: gopassPart{Encoding: gopassEncodingRaw}}}
+	s.ks = gs
+
+	var policyFile = filepath.Join(t.TempDir(), appPolicyFilename)
+	assert.Nil(t, os.WriteFile(policyFile, []byte("require-expiry: true\n"), 0600))
+
+	// expiry can not be stored, generated ssh-key is rolled back
+	_, err := run("--policy", policyFile, "secret", "generate", "--expires", "30d", "test/key")
+	assert.Equal(t, errCodePolicyViolation, getAppError(err).Code)
+	assert.Contains(t, err.Error(), policyViolationNoMetadata)
+	assert.Empty(t, store.secrets)
+
+	_, err = run("secret", "generate", "test/key")
+	assert.Nil(t, err)
+
+	out, err := run("--policy", policyFile, "--output", "json", "policy", "check")
+	assert.Equal(t, errCodePolicyViolation, getAppError(err).Code)
+
+	var res []policyResult
+	assert.Nil(t, json.Unmarshal([]byte(out), &res))
+	assert.Equal(t, []policyResult{{Key: "ssh-keys/test/key", Violations: []string{policyViolationNoMetadata}}}, res)
+}
+
+func TestCommandsPolicyInsertEncryptedPrivateKey(t *testing.T) {
+	_, ms, run := newTestGc()
+
+	var policyFile = filepath.Join(t.TempDir(), appPolicyFilename)
+	assert.Nil(t, os.WriteFile(policyFile, []byte("key-types: [ed25519]\n"), 0600))
+
+	// legacy encrypted PEM private key, public key is unknown without passphrase
+	privKey, pubKey, err := sshKeygen(sshKeyTypeRsa, "secret", 2048)
+	assert.Nil(t, err)
+
+	insert := func() error {
+		r, w, err := os.Pipe()
+		assert.Nil(t, err)
+		go func() {
+			w.Write(privKey)
+			w.Close()
+		}()
+
+		var stdin = os.Stdin
+		os.Stdin = r
+		defer func() { os.Stdin = stdin }()
+
+		_, err = run("--policy", policyFile, "secret", "key", "private", "insert", "test/rsa")
+		return err
+	}
+
+	err = insert()
+	assert.ErrorContains(t, err, "insert its password or public ssh-key first")
+
+	assert.Nil(t, ms.setPublicSSHKey("ssh-keys/test/rsa", pubKey))
+	err = insert()
+	assert.Equal(t, errCodePolicyViolation, getAppError(err).Code)
+	assert.Contains(t, err.Error(), "ssh-key type rsa is not allowed (ed25519)")
+	assert.Empty(t, ms.privateKeys)
+}
@@ -0,0 +1,168 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/urfave/cli/v2"
+	"golang.org/x/crypto/ssh"
+)
+
+// searchFilter is `secret search` filter, patterns support '*' and '?', empty fields are not checked
+type searchFilter struct {
+	path        string
+	keyType     string
+	comment     string
+	fingerprint string
+	metadata    map[string]string
+	expiresIn   time.Duration
+	expired     bool
+}
+
+// searchResult is structured result of found ssh-key
+type searchResult struct {
+	Key         string            `json:"key" yaml:"key"`
+	Type        string            `json:"type" yaml:"type"`
+	Bits        int               `json:"bits" yaml:"bits"`
+	Comment     string            `json:"comment" yaml:"comment"`
+	Fingerprint string            `json:"fingerprint" yaml:"fingerprint"`
+	Metadata    map[string]string `json:"metadata,omitempty" yaml:"metadata,omitempty"`
+}
+
+// getSearchFilter returns search filter from command flags, metadata filters are `name=pattern`
+// (`name` alone matches ssh-keys with the field set)
+func getSearchFilter(c *cli.Context) (f searchFilter, err error) {
+	f = searchFilter{
+		path:     c.String("path"),
+		keyType:  strings.ToLower(c.String("type")),
+		comment:  c.String("comment"),
+		expired:  c.Bool("expired"),
+		metadata: make(map[string]string),
+	}
+
+	if fp := c.String("fingerprint"); fp != "" {
+		f.fingerprint = normalizeFingerprint(fp)
+	}
+
+	for _, m := range c.StringSlice("meta") {
+		name, pattern, found := strings.Cut(m, "=")
+		if name == "" {
+			return f, newUsageError("invalid metadata filter '%s' (name=pattern)", m)
+		}
+		if !found {
+			pattern = "?*"
+		}
+		f.metadata[name] = pattern
+	}
+
+	if v := c.String("expires-within"); v != "" {
+		f.expiresIn, err = parseDuration(v)
+	}
+	return
+}
+
+// matchMetadata checks metadata fields and expiry
+func (f searchFilter) matchMetadata(meta map[string]string, now time.Time) bool {
+	for name, pattern := range f.metadata {
+		if !sshWildcardMatch(pattern, meta[name]) {
+			return false
+		}
+	}
+
+	if f.expiresIn == 0 && !f.expired {
+		return true
+	}
+
+	expires, err := parseMetadataTime(meta[gopassMetaExpires])
+	if err != nil {
+		return false
+	}
+	if f.expired && expires.After(now) {
+		return false
+	}
+	return f.expiresIn == 0 || expires.Sub(now) <= f.expiresIn
+}
+
+// matchPublicKey checks public ssh-key type, comment and fingerprint
+func (f searchFilter) matchPublicKey(res searchResult, pubKey ssh.PublicKey) bool {
+	if f.keyType != "" && !sshWildcardMatch(f.keyType, strings.ToLower(res.Type)) {
+		return false
+	}
+	if f.comment != "" && !sshWildcardMatch(f.comment, res.Comment) {
+		return false
+	}
+	if f.fingerprint != "" && !matchFingerprint(pubKey, f.fingerprint) {
+		return false
+	}
+	return true
+}
+
+// Search - find ssh-keys by path, type, comment, fingerprint, metadata or expiry, only public parts are read
+func (s *gc) Search(c *cli.Context) error {
+	var store = c.String("store")
+
+	f, err := getSearchFilter(c)
+	if err != nil {
+		return err
+	}
+
+	s.log().Info("getting ssh-keys list from gopass")
+	paths, err := s.ks.list(store)
+	if err != nil {
+		return err
+	}
+
+	var now = time.Now()
+	var o = make([]searchResult, 0)
+	var text strings.Builder
+	for _, p := range paths {
+		if f.path != "" && !sshWildcardMatch(f.path, p) {
+			continue
+		}
+
+		var key = filepath.Join(store, p)
+		var log = s.log().WithField("gkey", key)
+
+		log.Debug("getting public ssh-key from gopass")
+		pubKeyB, err := s.ks.getPublicSSHKey(key)
+		if err != nil {
+			if err.Error() == ErrNotFound.Error() {
+				continue
+			}
+			return err
+		}
+
+		pubKey, _, _, _, err := ssh.ParseAuthorizedKey(pubKeyB)
+		if err != nil {
+			log.Warnf("skipping: %s", err)
+			continue
+		}
+
+		var res = searchResult{
+			Key:         key,
+			Type:        getSSHKeyTypeName(pubKey),
+			Bits:        getSSHKeyBits(pubKey),
+			Comment:     getPublicSSHKeyComment(pubKeyB, key),
+			Fingerprint: ssh.FingerprintSHA256(pubKey),
+		}
+		if !f.matchPublicKey(res, pubKey) {
+			continue
+		}
+
+		res.Metadata, err = s.ks.getMetadata(key)
+		if err != nil && !errors.Is(err, errMetadataNotSupported) {
+			return err
+		}
+		if !f.matchMetadata(res.Metadata, now) {
+			continue
+		}
+
+		o = append(o, res)
+		fmt.Fprintf(&text, "%s\t%s %d\t%s\t%s\n", res.Key, res.Type, res.Bits, res.Fingerprint, res.Comment)
+	}
+
+	return s.printResult(o, text.String())
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/ssh"
+)
+
+// publicOnlyKeyStore fails test if password or private ssh-key is read
+type publicOnlyKeyStore struct {
+	*memoryKeyStore
+	t *testing.T
+}
+
+func (ks publicOnlyKeyStore) getPassword(key string) (string, error) {
+	ks.t.Errorf("password of '%s' is read", key)
+	return "", ErrNotFound
+}
+
+func (ks publicOnlyKeyStore) getPrivateSSHKey(key string) ([]byte, error) {
+	ks.t.Errorf("private ssh-key of '%s' is read", key)
+	return nil, ErrNotFound
+}
+
+func TestCommandsSearch(t *testing.T) {
+	s, ms, run := newTestGc()
+
+	_, err := run("secret", "generate", "--comment", "dev@example.com", "--expires", "10d", "prod/deploy")
+	assert.Nil(t, err)
+	_, err = run("secret", "generate", "--type", "rsa", "--bits", "2048", "dev/legacy")
+	assert.Nil(t, err)
+	_, err = run("secret", "metadata", "set", "dev/legacy", "principals=ops@example.com")
+	assert.Nil(t, err)
+
+	// only public parts are read
+	s.ks = publicOnlyKeyStore{memoryKeyStore: ms, t: t}
+
+	search := func(args ...string) (keys []string) {
+		out, err := run(append([]string{"--output", "json", "secret", "search"}, args...)...)
+		assert.Nil(t, err)
+
+		var res []searchResult
+		assert.Nil(t, json.Unmarshal([]byte(out), &res))
+		for _, r := range res {
+			keys = append(keys, r.Key)
+		}
+		return
+	}
+
+	assert.Equal(t, []string{"ssh-keys/dev/legacy", "ssh-keys/prod/deploy"}, search())
+	assert.Equal(t, []string{"ssh-keys/prod/deploy"}, search("--path", "prod/*"))
+	assert.Equal(t, []string{"ssh-keys/dev/legacy"}, search("--type", "RSA"))
+	assert.Equal(t, []string{"ssh-keys/prod/deploy"}, search("--comment", "*@example.com"))
+	assert.Equal(t, []string{"ssh-keys/dev/legacy"}, search("--meta", "principals=ops@*"))
+	assert.Equal(t, []string{"ssh-keys/dev/legacy"}, search("--meta", "principals"))
+	assert.Equal(t, []string{"ssh-keys/prod/deploy"}, search("--expires-within", "2w"))
+	assert.Empty(t, search("--expired"))
+
+	pubKey, _, _, _, err := ssh.ParseAuthorizedKey(ms.publicKeys["ssh-keys/dev/legacy"])
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"ssh-keys/dev/legacy"}, search("--fingerprint", ssh.FingerprintSHA256(pubKey)))
+	assert.Equal(t, []string{"ssh-keys/dev/legacy"}, search("--fingerprint", ssh.FingerprintLegacyMD5(pubKey)))
+	assert.Empty(t, search("--fingerprint", "SHA256:unknown"))
+}
@@ -4,12 +4,18 @@ import (
 	"bytes"
 	"errors"
 	"fmt"
+	"io"
+	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 	"time"
 
 	"github.com/apex/log"
+	apexlogcli "github.com/apex/log/handlers/cli"
+	apexlogjson "github.com/apex/log/handlers/json"
 	"github.com/urfave/cli/v2"
+	"golang.org/x/crypto/ssh"
 )
 
 var (
@@ -17,12 +23,15 @@ var (
 )
 
 type gc struct {
-	gs     *gopassStorage
-	sa     *sshAgent
-	cb     *clp
+	ks     keyStore
+	sa     keyAgent
+	cb     clipboardCopier
+	cfg    appConfig
 	logger log.Logger
 	key    string
 	yes    bool
+	output string
+	stdout io.Writer
 }
 
 func (s gc) log() *log.Entry {
@@ -32,38 +41,130 @@ func (s gc) log() *log.Entry {
 	return s.logger.WithField("gkey", s.key)
 }
 
-func (s *gc) PathAutocomplete(c *cli.Context) {
-	var store = c.String("store")
-	ll, err := s.gs.list(store)
+func (s *gc) BeforeBase(c *cli.Context) error {
+	err := s.applyConfig(c, "")
 	if err != nil {
-		panic(err)
+		return err
 	}
 
-	fmt.Println(strings.Join(ll, "\n"))
-}
-
-func (s *gc) BeforeBase(c *cli.Context) error {
 	var silent = c.Bool("quiet")
 	s.yes = c.Bool("yes")
+	s.output = c.String("output")
+
+	err = checkAppOutput(s.output)
+	if err != nil {
+		return err
+	}
+
+	err = s.openContextKeyStore(c)
+	if err != nil {
+		return err
+	}
 
-	if silent {
+	return s.setLogger(c.String("log-format"), getVerbosity(c), silent)
+}
+
+// setLogger sets log handler and level, verbosity 1 is debug level, 2 is trace level with gopass api timings
+func (s *gc) setLogger(format string, verbosity int, silent bool) error {
+	switch format {
+	case appLogFormatText:
+		s.logger.Handler = apexlogcli.New(os.Stderr)
+	case appLogFormatJSON:
+		s.logger.Handler = apexlogjson.New(os.Stderr)
+	default:
+		return newUsageError("unknown log format '%s' (%s, %s)", format, appLogFormatText, appLogFormatJSON)
+	}
+
+	switch {
+	case silent:
 		s.logger.Level = log.ErrorLevel
+	case verbosity > 0:
+		s.logger.Level = log.DebugLevel
+	}
+
+	if gs, ok := s.ks.(*gopassStorage); ok && !silent && verbosity > 1 {
+		gs.api = newGopassTraceStore(gs.api, &s.logger)
 	}
 
+	if len(s.cfg.files) > 0 {
+		s.log().Debugf("config files: %s", strings.Join(s.cfg.files, ", "))
+	}
 	return nil
 }
 
+// getVerbosity returns number of `-v` flags, urfave/cli counts one more when it copies
+// value to flag alias, value from environment is not counted at all
+func getVerbosity(c *cli.Context) int {
+	var n = c.Count("verbose")
+	switch {
+	case n > 1:
+		return n - 1
+	case n == 0 && c.Bool("verbose"):
+		return 1
+	}
+	return n
+}
+
+// getFlagValue returns flag value with config file default, before config is applied to flags
+func (s *gc) getFlagValue(c *cli.Context, name string) string {
+	if c.IsSet(name) {
+		return c.String(name)
+	}
+
+	if v, ok := s.cfg.values([]string{name}, "")[name]; ok {
+		return v[0]
+	}
+	return c.String(name)
+}
+
+// getDefaultFlagValue returns function returning global flag value from config file or flag default,
+// it is used when command line is not parsed
+func (s *gc) getDefaultFlagValue(app *cli.App) func(name string) string {
+	return func(name string) string {
+		if v, ok := s.cfg.values([]string{name}, "")[name]; ok {
+			return v[0]
+		}
+
+		for _, f := range app.Flags {
+			if df, ok := f.(cli.DocGenerationFlag); ok && f.Names()[0] == name {
+				return df.GetValue()
+			}
+		}
+		return ""
+	}
+}
+
 // Before is executed before another git-credential command.
 func (s *gc) Before(c *cli.Context) error {
-	if c.NArg() == 0 {
-		return errors.New("ssh-key path must be set")
+	// usage errors are printed in requested output format as well
+	s.output = c.String("output")
+
+	sshKeyPath := c.Args().Get(0)
+	if sshKeyPath == "" {
+		if !isTerminal() {
+			return newUsageError("ssh-key path must be set")
+		}
+
+		err := s.openContextKeyStore(c)
+		if err != nil {
+			return err
+		}
+
+		sshKeyPath, err = s.pickSSHKey(s.getFlagValue(c, "store"))
+		if err != nil {
+			return err
+		}
+	}
+
+	err := s.applyConfig(c, sshKeyPath)
+	if err != nil {
+		return err
 	}
 
 	store := c.String("store")
-	sshKeyPath := c.Args().Get(0)
 	s.key = filepath.Join(store, sshKeyPath)
 
-	err := s.BeforeBase(c)
+	err = s.BeforeBase(c)
 	if err != nil {
 		return err
 	}
@@ -84,39 +185,66 @@ func (s gc) confirm(q string, qq ...any) bool {
 	return false
 }
 
+// addToAgent adds ssh-key saved in gopass to ssh-agent
+func (s *gc) addToAgent(sa keyAgent, key string, lifetime uint32, confirm bool) error {
+	var log = s.log().WithField("gkey", key)
+
+	log.Info("getting private ssh-key from gopass")
+	privKey, err := s.ks.getPrivateSSHKey(key)
+	if err != nil {
+		return err
+	}
+
+	log.Info("getting ssh-key passphrase from gopass")
+	password, err := s.ks.getPassword(key)
+	if err != nil {
+		return err
+	}
+
+	return sa.addConfirmed(privKey, password, key, lifetime, confirm)
+}
+
+// checkAgent fails if there is no ssh-agent to manage keys of
+func (s *gc) checkAgent() error {
+	if _, ok := s.sa.(missingAgent); ok {
+		return errNoAgent
+	}
+	return nil
+}
+
 // SSHAdd - adding ssh-key to ssh-agent
 func (s *gc) SSHAdd(c *cli.Context) error {
-	s.log().Info("getting private ssh-key from gopass")
-	privKey, err := s.gs.getPrivateSSHKey(s.key)
-	if err != nil {
+	if err := s.checkAgent(); err != nil {
 		return err
 	}
 
-	s.log().Info("getting ssh-key passphrase from gopass")
-	password, err := s.gs.getPassword(s.key)
+	lifetime, err := s.getAgentLifetime(s.key, c.Int("lifetime"), c.Bool("allow-expired"))
 	if err != nil {
 		return err
 	}
 
-	lifetime := c.Int("lifetime")
 	strDur, err := time.ParseDuration(fmt.Sprintf("%ds", lifetime))
 	if err != nil {
 		return err
 	}
 
 	s.log().Infof("adding private ssh-key to ssh-agent for %s", strDur)
-	err = s.sa.add(privKey, password, s.key, uint32(lifetime))
+	err = s.addToAgent(s.sa, s.key, uint32(lifetime), c.Bool("confirm"))
 	if err != nil {
 		return err
 	}
 
-	return nil
+	return s.printResult(sshKeyResult{Key: s.key, Action: "added", Lifetime: lifetime}, "")
 }
 
 // SSHAdd - delete ssh-key from ssh-agent
 func (s *gc) SSHDelete(c *cli.Context) error {
+	if err := s.checkAgent(); err != nil {
+		return err
+	}
+
 	s.log().Info("getting public ssh-key from gopass")
-	pubKey, err := s.gs.getPublicSSHKey(s.key)
+	pubKey, err := s.ks.getPublicSSHKey(s.key)
 	if err != nil {
 		return err
 	}
@@ -127,25 +255,39 @@ func (s *gc) SSHDelete(c *cli.Context) error {
 		return err
 	}
 
-	return nil
+	return s.printResult(sshKeyResult{Key: s.key, Action: "deleted"}, "")
 }
 
 // SSHList - show ssh key in ssh-agent (`ssh-add -l`)
 func (s *gc) SSHList(c *cli.Context) error {
+	if err := s.checkAgent(); err != nil {
+		return err
+	}
+
 	s.log().Info("getting ssh-keys list from ssh-agent")
-	ll, err := s.sa.list()
+	keys, err := s.sa.listKeys()
 	if err != nil {
 		return err
 	}
 
-	fmt.Println(strings.Join(ll, "\n\n"))
-	return nil
+	var o = make([]sshAgentKeyResult, 0, len(keys))
+	var ll = make([]string, 0, len(keys))
+	for _, key := range keys {
+		o = append(o, newSSHAgentKeyResult(key))
+		ll = append(ll, key.String())
+	}
+
+	return s.printResult(o, strings.Join(ll, "\n\n")+"\n")
 }
 
 // SSHClear - delete all ssh keys from ssh-agent (`ssh-add -D`)
 func (s *gc) SSHClear(c *cli.Context) error {
+	if err := s.checkAgent(); err != nil {
+		return err
+	}
+
 	if !s.confirm("Are you sure you want to remove all ssh-keys from ssh-agent?") {
-		return nil
+		return errCancelled
 	}
 
 	s.log().Info("removing all ssh-keys from ssh-agent")
@@ -153,81 +295,138 @@ func (s *gc) SSHClear(c *cli.Context) error {
 	if err != nil {
 		return err
 	}
-	return nil
+	return s.printResult(sshKeyResult{Action: "cleared"}, "")
 }
 
 // SSHKeygen - generate ssh-key and save it to gopass
 func (s *gc) SSHKeygen(c *cli.Context) error {
 	var sshKeyType = c.String("type")
-	// var sshKeyComment = c.String("comment")
+	var sshKeyComment = c.String("comment")
 	var sshKeyBits = c.Int("bits")
-	var passLen = c.Int("length")
-	var includeSymbols = c.Bool("symbols")
 
-	s.log().Infof("generating ssh-key passphrase with length - %d", passLen)
-	passwd, err := genPassword(passLen, includeSymbols)
+	policy, err := s.getPolicy(c)
 	if err != nil {
 		return err
 	}
 
-	s.log().Infof("generating %s ssh-keys", sshKeyType)
-	privKey, pubKey, err := sshKeygen(sshKeyType, passwd, sshKeyBits)
-	if err != nil {
-		return err
+	var now = time.Now().UTC()
+	var meta = map[string]string{
+		gopassMetaCreated: now.Format(time.RFC3339),
 	}
 
-	if !s.confirm("Are you sure you want to save generated ssh-key and password to gopass ('%s')?", s.key) {
-		return nil
+	if v := c.String("expires"); v != "" {
+		expires, err := parseExpiry(v, now)
+		if err != nil {
+			return err
+		}
+		meta[gopassMetaExpires] = expires.Format(time.RFC3339)
 	}
 
-	s.log().Info("saving password to gopass")
-	err = s.gs.setPassword(s.key, passwd)
+	passwd, entropy, err := s.generatePassword(getPasswordOptions(c))
 	if err != nil {
 		return err
 	}
 
-	s.log().Info("saving private ssh-key to gopass")
-	err = s.gs.setPrivateSSHKey(s.key, privKey)
+	s.log().Infof("generating %s ssh-keys", sshKeyType)
+	privKey, pubKey, err := sshKeygen(sshKeyType, passwd, sshKeyBits)
 	if err != nil {
 		return err
 	}
 
-	s.log().Info("saving public ssh-key to gopass")
-	err = s.gs.setPublicSSHKey(s.key, pubKey)
-	if err != nil {
-		return err
+	if sshKeyComment != "" {
+		pubKey = append(bytes.TrimSpace(pubKey), []byte(" "+sshKeyComment+"\n")...)
 	}
 
-	return nil
+	if policy != nil {
+		s.log().Info("checking ssh-key policy")
+		parsedPubKey, _, _, _, err := ssh.ParseAuthorizedKey(pubKey)
+		if err != nil {
+			return err
+		}
+
+		var violations = policy.checkKey(parsedPubKey, getPublicSSHKeyComment(pubKey, s.key))
+		violations = append(violations, policy.checkPassword(passwd, entropy)...)
+		violations = append(violations, policy.checkMetadata(meta)...)
+		if len(violations) > 0 {
+			return newPolicyError(s.key, violations)
+		}
+	}
+
+	if !s.confirm("Are you sure you want to save generated ssh-key and password to gopass ('%s')?", s.key) {
+		return errCancelled
+	}
+
+	err = s.ks.transaction(func() error {
+		s.log().Info("saving password to gopass")
+		err := s.ks.setPassword(s.key, passwd)
+		if err != nil {
+			return err
+		}
+
+		s.log().Info("saving private ssh-key to gopass")
+		err = s.ks.setPrivateSSHKey(s.key, privKey)
+		if err != nil {
+			return err
+		}
+
+		s.log().Info("saving public ssh-key to gopass")
+		err = s.ks.setPublicSSHKey(s.key, pubKey)
+		if err != nil {
+			return err
+		}
+
+		s.log().Info("saving ssh-key metadata to gopass")
+		err = s.ks.setMetadata(s.key, meta)
+		if errors.Is(err, errMetadataNotSupported) {
+			// ssh-key is rolled back, it would violate policy without stored expiry
+			if policy.requiresMetadata() {
+				return newPolicyError(s.key, []string{policyViolationNoMetadata})
+			}
+			s.log().Warn(err.Error())
+			return nil
+		}
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	return s.printResult(sshKeyResult{
+		Key:         s.key,
+		Action:      "generated",
+		PublicKey:   string(getPublicSSHKeyWithComment(pubKey, s.key)),
+		Fingerprint: getSSHKeyFingerprint(pubKey),
+		Entropy:     entropy,
+	}, "")
 }
 
 // Delete - delete ssh-key secret completely
 func (s *gc) Delete(c *cli.Context) error {
-	var err error
-
 	if !s.confirm("Are you sure you want to DELETE ssh-key secret from gopass ('%s')?", s.key) {
-		return nil
+		return errCancelled
 	}
 
-	s.log().Info("deleting password gopass secret")
-	err = s.gs.delPassword(s.key)
-	if err != nil {
-		return err
-	}
+	err := s.ks.transaction(func() error {
+		s.log().Info("deleting password gopass secret")
+		err := s.ks.delPassword(s.key)
+		if err != nil {
+			return err
+		}
 
-	s.log().Info("deleting private ssh-key gopass secret")
-	err = s.gs.delPrivateSSHKey(s.key)
-	if err != nil {
-		return err
-	}
+		s.log().Info("deleting private ssh-key gopass secret")
+		err = s.ks.delPrivateSSHKey(s.key)
+		if err != nil {
+			return err
+		}
 
-	s.log().Info("deleting public ssh-key gopass secret")
-	err = s.gs.delPublicSSHKey(s.key)
+		s.log().Info("deleting public ssh-key gopass secret")
+		return s.ks.delPublicSSHKey(s.key)
+	})
 	if err != nil {
 		return err
 	}
 
-	return nil
+	return s.printResult(sshKeyResult{Key: s.key, Action: "deleted"}, "")
 }
 
 // ShowPassword - show ssh-key password from gopass secret
@@ -235,23 +434,26 @@ func (s *gc) ShowPassword(c *cli.Context) error {
 	var clip = c.Bool("clipboard")
 
 	s.log().Info("getting password from gopass")
-	password, err := s.gs.getPassword(s.key)
+	password, err := s.ks.getPassword(s.key)
 	if err != nil {
 		return err
 	}
 
 	if !clip {
-		fmt.Println(password)
-		return nil
+		return s.printResult(sshKeyResult{Key: s.key, Password: password}, password+"\n")
+	}
+
+	fullKey, err := s.ks.partName(s.key, gopassKeySuffixPassword)
+	if err != nil {
+		return err
 	}
 
-	fullKey := s.gs.getPasswordPath(s.key)
 	err = s.cb.copy(fullKey, []byte(password), 45)
 	if err != nil {
 		return err
 	}
 
-	return nil
+	return s.printResult(sshKeyResult{Key: s.key, Action: "copied"}, "")
 }
 
 // DeletePassword - delete ssh-key password from gopass secret
@@ -259,82 +461,86 @@ func (s *gc) DeletePassword(c *cli.Context) error {
 	var err error
 
 	if !s.confirm("Are you sure you want to DELETE password from gopass ('%s')?", s.key) {
-		return nil
+		return errCancelled
 	}
 
 	s.log().Info("deleting password from gopass")
-	err = s.gs.delPassword(s.key)
+	err = s.ks.delPassword(s.key)
 	if err != nil {
 		return err
 	}
-	return nil
+	return s.printResult(sshKeyResult{Key: s.key, Action: "deleted"}, "")
 }
 
 // GeneratePassword - generating ssh-key password and save it in gopass secret
 func (s *gc) GeneratePassword(c *cli.Context) error {
-	var passLen = c.Int("length")
-	var includeSymbols = c.Bool("symbols")
-
-	s.log().Infof("generating password with length: %d", passLen)
-	passwd, err := genPassword(passLen, includeSymbols)
+	passwd, entropy, err := s.generatePassword(getPasswordOptions(c))
 	if err != nil {
 		return err
 	}
 
 	if !s.confirm("Are you sure you want to save generated password to gopass ('%s')?", s.key) {
-		return nil
+		return errCancelled
 	}
 
 	s.log().Info("saving password to gopass")
-	err = s.gs.setPassword(s.key, passwd)
+	err = s.ks.setPassword(s.key, passwd)
 	if err != nil {
 		return err
 	}
-	return nil
+	return s.printResult(sshKeyResult{Key: s.key, Action: "generated", Entropy: entropy}, "")
 }
 
 // InsertPassword - get ssh-key password from stdin and save it in gopass secret
 func (s *gc) InsertPassword(c *cli.Context) error {
-	s.log().Info("getting password from stdin")
-	data, err := getDataFromStdIn()
+	password, err := s.readPassword(c.String("input"), c.String("pinentry"),
+		fmt.Sprintf("Enter password of ssh-key '%s'", s.key))
 	if err != nil {
 		return err
 	}
 
+	policy, err := s.getPolicy(c)
+	if err != nil {
+		return err
+	}
+	if violations := policy.checkPassword(password, -1); len(violations) > 0 {
+		return newPolicyError(s.key, violations)
+	}
+
 	if !s.confirm("Are you sure you want to save imported password to gopass ('%s')?", s.key) {
-		return nil
+		return errCancelled
 	}
 
 	s.log().Info("saving password to gopass")
-	err = s.gs.setPassword(s.key, string(data))
+	err = s.ks.setPassword(s.key, password)
 	if err != nil {
 		return err
 	}
 
-	return nil
+	return s.printResult(sshKeyResult{Key: s.key, Action: "saved"}, "")
 }
 
 // DeleteSSHKey - delete ssh-key (private and puplic) from gopass secret
 func (s *gc) DeleteSSHKey(c *cli.Context) error {
-	var err error
-
 	if !s.confirm("Are you sure you want to DELETE ssh-key (private and public) from gopass ('%s')?", s.key) {
-		return nil
+		return errCancelled
 	}
 
-	s.log().Info("deleting private ssh-key from gopass")
-	err = s.gs.delPrivateSSHKey(s.key)
-	if err != nil {
-		return err
-	}
+	err := s.ks.transaction(func() error {
+		s.log().Info("deleting private ssh-key from gopass")
+		err := s.ks.delPrivateSSHKey(s.key)
+		if err != nil {
+			return err
+		}
 
-	s.log().Info("deleting public ssh-key from gopass")
-	err = s.gs.delPublicSSHKey(s.key)
+		s.log().Info("deleting public ssh-key from gopass")
+		return s.ks.delPublicSSHKey(s.key)
+	})
 	if err != nil {
 		return err
 	}
 
-	return nil
+	return s.printResult(sshKeyResult{Key: s.key, Action: "deleted"}, "")
 }
 
 // InsertSSHPrivateKey - get ssh-key private key from stdin and save it in gopass secret
@@ -345,16 +551,60 @@ func (s *gc) InsertSSHPrivateKey(c *cli.Context) error {
 		return err
 	}
 
+	err = s.checkPrivateSSHKeyPolicy(c, data)
+	if err != nil {
+		return err
+	}
+
 	if !s.confirm("Are you sure you want to save imported private ssh-key to gopass ('%s')?", s.key) {
-		return nil
+		return errCancelled
 	}
 
 	s.log().Info("saving private ssh-key to gopass")
-	err = s.gs.setPrivateSSHKey(s.key, data)
+	err = s.ks.setPrivateSSHKey(s.key, data)
 	if err != nil {
 		return err
 	}
 
+	return s.printResult(sshKeyResult{Key: s.key, Action: "saved"}, "")
+}
+
+// checkPrivateSSHKeyPolicy checks imported private ssh-key type, size and comment of stored public ssh-key
+func (s *gc) checkPrivateSSHKeyPolicy(c *cli.Context, data []byte) error {
+	policy, err := s.getPolicy(c)
+	if err != nil || policy == nil {
+		return err
+	}
+
+	s.log().Info("checking ssh-key policy")
+	password, err := s.ks.getPassword(s.key)
+	if err != nil && err.Error() != ErrNotFound.Error() {
+		return err
+	}
+
+	var comment = getSSHKeyComment(s.key)
+	var storedPubKey ssh.PublicKey
+	if pubKeyB, err := s.ks.getPublicSSHKey(s.key); err == nil {
+		comment = getPublicSSHKeyComment(pubKeyB, s.key)
+		storedPubKey, _, _, _, _ = ssh.ParseAuthorizedKey(pubKeyB)
+	}
+
+	// legacy encrypted PEM key has no public key without passphrase, stored public ssh-key is checked then
+	pubKey, err := getPrivateSSHKeyPublicKey(data, password)
+	var missingErr *ssh.PassphraseMissingError
+	switch {
+	case err == nil:
+	case errors.As(err, &missingErr) && storedPubKey != nil:
+		pubKey = storedPubKey
+	case errors.As(err, &missingErr):
+		return errors.New("cannot check ssh-key policy of encrypted private ssh-key: insert its password or public ssh-key first")
+	default:
+		return fmt.Errorf("cannot check ssh-key policy: %w", err)
+	}
+
+	if violations := policy.checkKey(pubKey, comment); len(violations) > 0 {
+		return newPolicyError(s.key, violations)
+	}
 	return nil
 }
 
@@ -363,23 +613,26 @@ func (s *gc) ShowSSHPrivateKey(c *cli.Context) error {
 	var clip = c.Bool("clipboard")
 
 	s.log().Info("getting private ssh-key from gopass")
-	privKey, err := s.gs.getPrivateSSHKey(s.key)
+	privKey, err := s.ks.getPrivateSSHKey(s.key)
 	if err != nil {
 		return err
 	}
 
 	if !clip {
-		fmt.Println(string(privKey))
-		return nil
+		return s.printResult(sshKeyResult{Key: s.key, PrivateKey: string(privKey)}, string(privKey)+"\n")
+	}
+
+	fullKey, err := s.ks.partName(s.key, gopassKeySuffixPrivateKey)
+	if err != nil {
+		return err
 	}
 
-	fullKey := s.gs.getPrivateKeyPath(s.key)
 	err = s.cb.copy(fullKey, []byte(privKey), 45)
 	if err != nil {
 		return err
 	}
 
-	return nil
+	return s.printResult(sshKeyResult{Key: s.key, Action: "copied"}, "")
 }
 
 // DeleteSSHPrivateKey - delete ssh-key private key
@@ -387,15 +640,15 @@ func (s *gc) DeleteSSHPrivateKey(c *cli.Context) error {
 	var err error
 
 	if !s.confirm("Are you sure you want to DELETE private ssh-key from gopass ('%s')?", s.key) {
-		return nil
+		return errCancelled
 	}
 
 	s.log().Info("deleting private ssh-key from gopass")
-	err = s.gs.delPrivateSSHKey(s.key)
+	err = s.ks.delPrivateSSHKey(s.key)
 	if err != nil {
 		return err
 	}
-	return nil
+	return s.printResult(sshKeyResult{Key: s.key, Action: "deleted"}, "")
 }
 
 // InsertSSHPublicKey - get ssh-key public key from stdin and save it in gopass secret
@@ -407,16 +660,16 @@ func (s *gc) InsertSSHPublicKey(c *cli.Context) error {
 	}
 
 	if !s.confirm("Are you sure you want to save imported public ssh-key to gopass ('%s')?", s.key) {
-		return nil
+		return errCancelled
 	}
 
 	s.log().Info("saving public ssh-key to gopass")
-	err = s.gs.setPublicSSHKey(s.key, data)
+	err = s.ks.setPublicSSHKey(s.key, data)
 	if err != nil {
 		return err
 	}
 
-	return nil
+	return s.printResult(sshKeyResult{Key: s.key, Action: "saved"}, "")
 }
 
 // ShowSSHPublicKey - show ssh-key public key
@@ -424,11 +677,33 @@ func (s *gc) ShowSSHPublicKey(c *cli.Context) error {
 	var clip = c.Bool("clipboard")
 
 	s.log().Info("getting public ssh-key from gopass")
-	pubKey, err := s.gs.getPublicSSHKey(s.key)
+	pubKey, err := s.ks.getPublicSSHKey(s.key)
+	if err != nil {
+		return err
+	}
+
+	pubKey = getPublicSSHKeyWithComment(pubKey, s.key)
+	var pubKeyStr = string(pubKey)
+
+	if !clip {
+		return s.printResult(sshKeyResult{Key: s.key, PublicKey: pubKeyStr}, pubKeyStr+"\n")
+	}
+
+	fullKey, err := s.ks.partName(s.key, gopassKeySuffixPublicKey)
 	if err != nil {
 		return err
 	}
 
+	err = s.cb.copy(fullKey, []byte(pubKey), 45)
+	if err != nil {
+		return err
+	}
+
+	return s.printResult(sshKeyResult{Key: s.key, Action: "copied"}, "")
+}
+
+// getPublicSSHKeyWithComment returns first line of public ssh-key with comment added if it is missing
+func getPublicSSHKeyWithComment(pubKey []byte, key string) []byte {
 	pubKey = bytes.SplitN(pubKey, []byte{'\n'}, 2)[0]
 	var pubKeySpaceParts = bytes.SplitN(pubKey, []byte{' '}, 3)
 	if len(pubKeySpaceParts) == 3 && bytes.Equal(pubKeySpaceParts[2], []byte("noname")) {
@@ -436,39 +711,99 @@ func (s *gc) ShowSSHPublicKey(c *cli.Context) error {
 	}
 
 	if len(pubKeySpaceParts) < 3 {
-		var comment = getSSHKeyComment(s.key)
+		var comment = getSSHKeyComment(key)
 		pubKeySpaceParts = append(pubKeySpaceParts, []byte(comment))
 		pubKey = bytes.Join(pubKeySpaceParts, []byte{' '})
 	}
 
-	var pubKeyStr = string(pubKey)
+	return pubKey
+}
 
-	if !clip {
-		fmt.Println(pubKeyStr)
-		return nil
+// DeleteSSHPublicKey - delete ssh-key public key
+func (s *gc) DeleteSSHPublicKey(c *cli.Context) error {
+	var err error
+
+	if !s.confirm("Are you sure you want to DELETE public ssh-key from gopass ('%s')?", s.key) {
+		return errCancelled
 	}
 
-	fullKey := s.gs.getPublicKeyPath(s.key)
-	err = s.cb.copy(fullKey, []byte(pubKey), 45)
+	s.log().Info("deleting public ssh-key from gopass")
+	err = s.ks.delPublicSSHKey(s.key)
 	if err != nil {
 		return err
 	}
+	return s.printResult(sshKeyResult{Key: s.key, Action: "deleted"}, "")
+}
 
-	return nil
+// ShowMetadata - show ssh-key metadata
+func (s *gc) ShowMetadata(c *cli.Context) error {
+	s.log().Info("getting ssh-key metadata from gopass")
+	meta, err := s.ks.getMetadata(s.key)
+	if err != nil {
+		return err
+	}
+
+	var keys = make([]string, 0, len(meta))
+	for k := range meta {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var text strings.Builder
+	for _, k := range keys {
+		fmt.Fprintf(&text, "%s: %s\n", k, meta[k])
+	}
+
+	return s.printResult(sshKeyResult{Key: s.key, Metadata: meta}, text.String())
 }
 
-// DeleteSSHPublicKey - delete ssh-key public key
-func (s *gc) DeleteSSHPublicKey(c *cli.Context) error {
-	var err error
+// SetMetadata - set ssh-key metadata fields
+func (s *gc) SetMetadata(c *cli.Context) error {
+	var meta = make(map[string]string)
+	for _, arg := range c.Args().Tail() {
+		k, v, found := strings.Cut(arg, "=")
+		if !found || k == "" || v == "" {
+			return newUsageError("invalid metadata field '%s', expected key=value", arg)
+		}
+		if k == gopassHeaderContentDisposition || k == gopassHeaderContentTransferEncoding {
+			return newUsageError("metadata field '%s' is reserved", k)
+		}
+		meta[k] = v
+	}
 
-	if !s.confirm("Are you sure you want to DELETE public ssh-key from gopass ('%s')?", s.key) {
-		return nil
+	if len(meta) == 0 {
+		return newUsageError("metadata fields must be set")
 	}
 
-	s.log().Info("deleting public ssh-key from gopass")
-	err = s.gs.delPublicSSHKey(s.key)
+	s.log().Info("saving ssh-key metadata to gopass")
+	err := s.ks.setMetadata(s.key, meta)
 	if err != nil {
 		return err
 	}
-	return nil
+
+	return s.printResult(sshKeyResult{Key: s.key, Action: "saved"}, "")
+}
+
+// DeleteMetadata - delete ssh-key metadata fields
+func (s *gc) DeleteMetadata(c *cli.Context) error {
+	var meta = make(map[string]string)
+	for _, k := range c.Args().Tail() {
+		meta[k] = ""
+	}
+
+	if len(meta) == 0 {
+		return newUsageError("metadata fields must be set")
+	}
+
+	if !s.confirm("Are you sure you want to DELETE metadata fields from gopass ('%s')?", s.key) {
+		return errCancelled
+	}
+
+	s.log().Info("deleting ssh-key metadata from gopass")
+	err := s.ks.setMetadata(s.key, meta)
+	if err != nil {
+		return err
+	}
+
+	return s.printResult(sshKeyResult{Key: s.key, Action: "deleted"}, "")
 }
@@ -0,0 +1,189 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/apex/log"
+	"github.com/apex/log/handlers/discard"
+	"github.com/stretchr/testify/assert"
+)
+
+// newTestGc returns gc with in-memory storage, run executes command line and returns stdout
+func newTestGc() (*gc, *memoryKeyStore, func(args ...string) (string, error)) {
+	ms := newMemoryKeyStore()
+	out := &bytes.Buffer{}
+	s := &gc{
+		ks:     ms,
+		sa:     newSSHKeyringAgent(),
+		stdout: out,
+		logger: log.Logger{Handler: discard.New(), Level: log.ErrorLevel},
+	}
+
+	return s, ms, func(args ...string) (string, error) {
+		out.Reset()
+		err := newApp(s).Run(append([]string{appName, "--quiet", "--yes"}, args...))
+		return out.String(), err
+	}
+}
+
+func TestCommandsSecret(t *testing.T) {
+	_, ms, run := newTestGc()
+
+	out, err := run("--output", "json", "secret", "generate", "--length", "20", "test/key")
+	assert.Nil(t, err)
+
+	var res sshKeyResult
+	assert.Nil(t, json.Unmarshal([]byte(out), &res))
+	assert.Equal(t, "ssh-keys/test/key", res.Key)
+	assert.Equal(t, "generated", res.Action)
+	assert.Equal(t, getSSHKeyFingerprint(ms.publicKeys[res.Key]), res.Fingerprint)
+	assert.Len(t, ms.passwords[res.Key], 20)
+	assert.Contains(t, ms.metadata[res.Key], gopassMetaCreated)
+
+	out, err = run("secret", "password", "show", "test/key")
+	assert.Nil(t, err)
+	assert.Equal(t, ms.passwords[res.Key]+"\n", out)
+
+	out, err = run("secret", "key", "public", "show", "test/key")
+	assert.Nil(t, err)
+	assert.Equal(t, res.PublicKey+"\n", out)
+
+	_, err = run("secret", "metadata", "set", "test/key", "owner=alice")
+	assert.Nil(t, err)
+	_, err = run("secret", "metadata", "delete", "test/key", gopassMetaCreated)
+	assert.Nil(t, err)
+
+	out, err = run("secret", "metadata", "show", "test/key")
+	assert.Nil(t, err)
+	assert.Equal(t, "owner: alice\n", out)
+
+	_, err = run("secret", "metadata", "set", "test/key", "owner")
+	assert.Equal(t, errCodeInvalidArgument, getAppError(err).Code)
+
+	_, err = run("secret", "delete", "test/key")
+	assert.Nil(t, err)
+	assert.Empty(t, ms.passwords)
+	assert.Empty(t, ms.privateKeys)
+	assert.Empty(t, ms.publicKeys)
+	assert.Empty(t, ms.metadata)
+
+	_, err = run("secret", "password", "show", "test/key")
+	assert.Equal(t, errCodeNotFound, getAppError(err).Code)
+}
+
+func TestCommandsAgent(t *testing.T) {
+	s, _, run := newTestGc()
+
+	_, err := run("secret", "generate", "test/key")
+	assert.Nil(t, err)
+
+	_, err = run("agent", "add", "--lifetime", "60", "test/key")
+	assert.Nil(t, err)
+
+	out, err := run("--output", "json", "agent", "list")
+	assert.Nil(t, err)
+
+	var keys []sshAgentKeyResult
+	assert.Nil(t, json.Unmarshal([]byte(out), &keys))
+	assert.Len(t, keys, 1)
+	assert.Equal(t, getSSHKeyComment("ssh-keys/test/key"), keys[0].Comment)
+
+	_, err = run("agent", "delete", "test/key")
+	assert.Nil(t, err)
+
+	added, err := s.sa.listKeys()
+	assert.Nil(t, err)
+	assert.Empty(t, added)
+
+	_, err = run("agent", "add", "test/key")
+	assert.Nil(t, err)
+	_, err = run("agent", "clear")
+	assert.Nil(t, err)
+
+	added, err = s.sa.listKeys()
+	assert.Nil(t, err)
+	assert.Empty(t, added)
+}
+
+func TestCommandsAgentMissing(t *testing.T) {
+	s, _, run := newTestGc()
+	s.sa = missingAgent{}
+
+	_, err := run("secret", "generate", "test/key")
+	assert.Nil(t, err)
+
+	for _, args := range [][]string{{"add", "test/key"}, {"delete", "test/key"}, {"list"}, {"clear"}} {
+		_, err = run(append([]string{"agent"}, args...)...)
+		assert.ErrorIs(t, err, errNoAgent)
+	}
+}
+
+func TestMemoryKeyStoreTransaction(t *testing.T) {
+	ms := newMemoryKeyStore()
+	assert.Nil(t, ms.setPassword("ssh/test", "old"))
+
+	err := ms.transaction(func() error {
+		assert.Nil(t, ms.setPassword("ssh/test", "new"))
+		assert.Nil(t, ms.setPublicSSHKey("ssh/test", []byte("public")))
+		return assert.AnError
+	})
+	assert.ErrorIs(t, err, assert.AnError)
+	assert.Equal(t, map[string]string{"ssh/test": "old"}, ms.passwords)
+	assert.Empty(t, ms.publicKeys)
+
+	ll, err := ms.list("ssh")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"test"}, ll)
+}
+
+// testClipboard records names of copied content
+type testClipboard struct {
+	names []string
+}
+
+func (tc *testClipboard) copy(name string, _ []byte, _ int) error {
+	tc.names = append(tc.names, name)
+	return nil
+}
+
+func TestCommandsShowClipboardPartNames(t *testing.T) {
+	s, _, run := newTestGc()
+	gs, _ := newTestGopassStorage()
+	gs.mounts = map[string]gopassMount{
+		"ssh-keys/team": {
+			Password:   gopassPart{Name: "passphrase"},
+			PrivateKey: gopassPart{Name: "id_ed25519"},
+			PublicKey:  gopassPart{Name: "id_ed25519.pub"},
+		},
+		"ssh-keys/single": {Layout: gopassLayoutSingle},
+	}
+	s.ks = gs
+
+	var cb = &testClipboard{}
+	s.cb = cb
+
+	for _, key := range []string{"team/key", "single/key"} {
+		_, err := run("secret", "generate", key)
+		assert.Nil(t, err)
+
+		for _, cmd := range [][]string{
+			{"secret", "password", "show", "--clipboard", key},
+			{"secret", "key", "private", "show", "--clipboard", key},
+			{"secret", "key", "public", "show", "--clipboard", key},
+		} {
+			_, err = run(cmd...)
+			assert.Nil(t, err)
+		}
+	}
+
+	assert.Equal(t, []string{
+		"ssh-keys/team/key/passphrase",
+		"ssh-keys/team/key/id_ed25519",
+		"ssh-keys/team/key/id_ed25519.pub",
+		"ssh-keys/single/key",
+		"ssh-keys/single/key",
+		"ssh-keys/single/key",
+	}, cb.names)
+}
@@ -16,38 +16,47 @@ const (
 	sshKeyTypeEd25519 = "ed25519"
 )
 
+// keyAgent is ssh-agent used by commands
+type keyAgent interface {
+	listKeys() ([]*agent.Key, error)
+	add(privateKeyB []byte, password, comment string, lifetime uint32) error
+	addConfirmed(privateKeyB []byte, password, comment string, lifetime uint32, confirm bool) error
+	delete(publicKeyB []byte) error
+	clear() error
+	signers() ([]ssh.Signer, error)
+}
+
+// sshAgent is keyAgent implementation for remote agent on SSH_AUTH_SOCK socket
+// and for in-process keyring
 type sshAgent struct {
 	agent agent.ExtendedAgent
 }
 
-func (sa sshAgent) list() (o []string, err error) {
-	keys, err := sa.agent.List()
-	if err != nil {
-		return
-	}
-
-	for _, key := range keys {
-		o = append(o, key.String())
-	}
+// listKeys returns ssh-keys added to ssh-agent
+func (sa sshAgent) listKeys() ([]*agent.Key, error) {
+	return sa.agent.List()
+}
 
-	return
+func (sa sshAgent) signers() ([]ssh.Signer, error) {
+	return sa.agent.Signers()
 }
 
 func (sa *sshAgent) add(privateKeyB []byte, password, comment string, lifetime uint32) (err error) {
-	var privateKey interface{}
-
-	decodedPem, _ := pem.Decode(privateKeyB)
-	keyIsEncrypted := x509.IsEncryptedPEMBlock(decodedPem)
+	return sa.addConfirmed(privateKeyB, password, comment, lifetime, false)
+}
 
-	privateKey, err = sa.decryptPrivateSSHKey(privateKeyB, password, keyIsEncrypted)
+// addConfirmed adds private key to ssh-agent, agent asks confirmation before every use if confirm is set
+func (sa *sshAgent) addConfirmed(privateKeyB []byte, password, comment string, lifetime uint32, confirm bool) (err error) {
+	privateKey, err := parsePrivateSSHKey(privateKeyB, password)
 	if err != nil {
 		return
 	}
 
 	key := agent.AddedKey{
-		PrivateKey:   privateKey,
-		LifetimeSecs: lifetime,
-		Comment:      getSSHKeyComment(comment),
+		PrivateKey:       privateKey,
+		LifetimeSecs:     lifetime,
+		ConfirmBeforeUse: confirm,
+		Comment:          getSSHKeyComment(comment),
 	}
 	err = sa.agent.Add(key)
 	if err != nil {
@@ -57,12 +66,20 @@ func (sa *sshAgent) add(privateKeyB []byte, password, comment string, lifetime u
 	return
 }
 
-func (sa sshAgent) decryptPrivateSSHKey(data []byte, password string, encrypted bool) (o interface{}, err error) {
+// parsePrivateSSHKey returns raw private key, decrypted with password if needed
+func parsePrivateSSHKey(data []byte, password string) (o interface{}, err error) {
+	decodedPem, _ := pem.Decode(data)
+	keyIsEncrypted := decodedPem != nil && x509.IsEncryptedPEMBlock(decodedPem)
+
+	return decryptPrivateSSHKey(data, password, keyIsEncrypted)
+}
+
+func decryptPrivateSSHKey(data []byte, password string, encrypted bool) (o interface{}, err error) {
 	if !encrypted {
 		o, err = ssh.ParseRawPrivateKey(data)
 		if err != nil {
 			if _, ok := err.(*ssh.PassphraseMissingError); ok {
-				return sa.decryptPrivateSSHKey(data, password, true)
+				return decryptPrivateSSHKey(data, password, true)
 			}
 		}
 		return o, err
@@ -107,6 +124,39 @@ func newSSHAgent() (sa *sshAgent, err error) {
 	}, nil
 }
 
+// newKeyAgent returns remote ssh-agent, agent commands fail if SSH_AUTH_SOCK is not set
+func newKeyAgent() (keyAgent, error) {
+	if os.Getenv("SSH_AUTH_SOCK") == "" {
+		return missingAgent{}, nil
+	}
+	return newSSHAgent()
+}
+
+var errNoAgent = newAppError(errCodeNotFound, errors.New("ssh-agent is not available (SSH_AUTH_SOCK is not set)"))
+
+// missingAgent is keyAgent used without SSH_AUTH_SOCK, it has no keys and refuses changes,
+// so keys are not added to in-process keyring which is lost on exit
+type missingAgent struct{}
+
+func (missingAgent) listKeys() ([]*agent.Key, error) { return nil, errNoAgent }
+
+func (missingAgent) add([]byte, string, string, uint32) error { return errNoAgent }
+
+func (missingAgent) addConfirmed([]byte, string, string, uint32, bool) error { return errNoAgent }
+
+func (missingAgent) delete([]byte) error { return errNoAgent }
+
+func (missingAgent) clear() error { return errNoAgent }
+
+func (missingAgent) signers() ([]ssh.Signer, error) { return nil, nil }
+
+// newSSHKeyringAgent returns in-process ssh-agent which keeps keys in memory only
+func newSSHKeyringAgent() *sshAgent {
+	return &sshAgent{
+		agent: agent.NewKeyring().(agent.ExtendedAgent),
+	}
+}
+
 func getSSHAgent() (sa agent.ExtendedAgent, err error) {
 	var sock net.Conn
 	sock, err = net.Dial("unix", os.Getenv("SSH_AUTH_SOCK"))
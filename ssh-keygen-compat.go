@@ -0,0 +1,322 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// ssh-keygen compatible CLI used by git with `gpg.format=ssh`:
+//
+//	git config gpg.ssh.program gopass-ssh-add
+const (
+	sshKeygenCompatOpSign            = "sign"
+	sshKeygenCompatOpVerify          = "verify"
+	sshKeygenCompatOpFindPrincipals  = "find-principals"
+	sshKeygenCompatOpCheckNovalidate = "check-novalidate"
+
+	sshKeygenCompatLiteralKeyPrefix = "key::"
+	sshKeygenCompatVerifyTimeOpt    = "verify-time"
+	sshKeygenCompatExitFailure      = 255
+)
+
+type sshKeygenCompatArgs struct {
+	operation string
+	namespace string
+	file      string
+	identity  string
+	signature string
+	useAgent  bool
+	options   []string
+	args      []string
+}
+
+// isSSHKeygenCompatCall checks program is called as `ssh-keygen -Y ...`
+func isSSHKeygenCompatCall(args []string) bool {
+	return len(args) > 0 && strings.HasPrefix(args[0], "-Y")
+}
+
+// parseSSHKeygenCompatArgs parses getopt-like arguments, option values may be attached (`-Overify-time=...`)
+func parseSSHKeygenCompatArgs(args []string) (o sshKeygenCompatArgs, err error) {
+	for i := 0; i < len(args); i++ {
+		var arg = args[i]
+		if arg == "--" {
+			o.args = append(o.args, args[i+1:]...)
+			break
+		}
+
+		if len(arg) < 2 || arg[0] != '-' {
+			o.args = append(o.args, arg)
+			continue
+		}
+
+		var opt = arg[1]
+		switch opt {
+		case 'U':
+			o.useAgent = true
+			continue
+		case 'q', 'v':
+			continue
+		case 'Y', 'n', 'f', 'I', 's', 'O':
+		default:
+			return o, fmt.Errorf("unsupported option '-%c'", opt)
+		}
+
+		var value = arg[2:]
+		if value == "" {
+			i++
+			if i >= len(args) {
+				return o, fmt.Errorf("option '-%c' requires an argument", opt)
+			}
+			value = args[i]
+		}
+
+		switch opt {
+		case 'Y':
+			o.operation = value
+		case 'n':
+			o.namespace = value
+		case 'f':
+			o.file = value
+		case 'I':
+			o.identity = value
+		case 's':
+			o.signature = value
+		case 'O':
+			o.options = append(o.options, value)
+		}
+	}
+
+	return o, nil
+}
+
+// verifyTime returns time from `-O verify-time=` option or current time
+func (a sshKeygenCompatArgs) verifyTime() (t time.Time, err error) {
+	for _, opt := range a.options {
+		name, value, _ := strings.Cut(opt, "=")
+		if name == sshKeygenCompatVerifyTimeOpt {
+			return parseAllowedSignersTime(value)
+		}
+	}
+	return time.Now(), nil
+}
+
+func (a sshKeygenCompatArgs) readSignature() (sig *sshSignature, err error) {
+	if a.signature == "" {
+		return nil, errors.New("signature file must be set")
+	}
+
+	data, err := os.ReadFile(a.signature)
+	if err != nil {
+		return
+	}
+
+	return parseSSHSignature(data)
+}
+
+// readSSHKeygenCompatPublicKey reads public key from file passed as `-f`, file can contain `key::` literal
+func readSSHKeygenCompatPublicKey(path string) (key ssh.PublicKey, err error) {
+	var data = []byte(path)
+	if !strings.HasPrefix(path, sshKeygenCompatLiteralKeyPrefix) {
+		data, err = os.ReadFile(path)
+		if err != nil {
+			return
+		}
+	}
+
+	data = bytes.TrimPrefix(bytes.TrimSpace(data), []byte(sshKeygenCompatLiteralKeyPrefix))
+	key, _, _, _, err = ssh.ParseAuthorizedKey(data)
+	if err == nil {
+		return
+	}
+
+	// signing key file can be private key, try to get public key from it
+	signer, perr := ssh.ParsePrivateKey(data)
+	if perr == nil {
+		return signer.PublicKey(), nil
+	}
+
+	// encrypted openssh private key contains public key
+	var missingErr *ssh.PassphraseMissingError
+	if errors.As(perr, &missingErr) && missingErr.PublicKey != nil {
+		return missingErr.PublicKey, nil
+	}
+	return nil, fmt.Errorf("cannot parse signing key '%s': %w", path, perr)
+}
+
+// SSHKeygenCompat - handle `ssh-keygen -Y` call and return exit code
+func (s *gc) SSHKeygenCompat(store string, args []string) int {
+	a, err := parseSSHKeygenCompatArgs(args)
+	if err == nil {
+		switch a.operation {
+		case sshKeygenCompatOpSign:
+			err = s.sshKeygenCompatSign(store, a)
+		case sshKeygenCompatOpVerify:
+			err = s.sshKeygenCompatVerify(a)
+		case sshKeygenCompatOpFindPrincipals:
+			err = s.sshKeygenCompatFindPrincipals(a)
+		case sshKeygenCompatOpCheckNovalidate:
+			err = s.sshKeygenCompatCheckNovalidate(a)
+		default:
+			err = fmt.Errorf("unsupported operation '%s'", a.operation)
+		}
+	}
+
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		return sshKeygenCompatExitFailure
+	}
+	return 0
+}
+
+// getCompatSigner returns signer for public key: ssh-key saved in gopass or key from ssh-agent if `-U` is set
+func (s *gc) getCompatSigner(store string, a sshKeygenCompatArgs) (signer ssh.Signer, err error) {
+	pubKey, err := readSSHKeygenCompatPublicKey(a.file)
+	if err != nil {
+		return
+	}
+
+	var fingerprint = ssh.FingerprintSHA256(pubKey)
+	key, err := findByFingerprint(s.ks, store, fingerprint)
+	if err == nil {
+		s.key = key
+		return s.getSigner(key)
+	}
+
+	if err.Error() != ErrNotFound.Error() || !a.useAgent || s.sa == nil {
+		return nil, fmt.Errorf("cannot find ssh-key %s in gopass ('%s'): %w", fingerprint, store, err)
+	}
+
+	s.log().Infof("ssh-key %s is not found in gopass, using ssh-agent", fingerprint)
+	signers, err := s.sa.signers()
+	if err != nil {
+		return
+	}
+
+	for _, signer := range signers {
+		if bytes.Equal(signer.PublicKey().Marshal(), pubKey.Marshal()) {
+			return signer, nil
+		}
+	}
+
+	return nil, fmt.Errorf("cannot find ssh-key %s in gopass ('%s') and ssh-agent", fingerprint, store)
+}
+
+func (s *gc) sshKeygenCompatSign(store string, a sshKeygenCompatArgs) error {
+	signer, err := s.getCompatSigner(store, a)
+	if err != nil {
+		return err
+	}
+
+	if len(a.args) == 0 {
+		a.args = []string{"-"}
+	}
+
+	for _, file := range a.args {
+		data, err := readDataFromFileOrStdIn(file)
+		if err != nil {
+			return err
+		}
+
+		sig, err := sshsigSign(signer, a.namespace, bytes.NewReader(data))
+		if err != nil {
+			return err
+		}
+
+		if file == "-" {
+			_, err = os.Stdout.Write(sig.armor())
+		} else {
+			err = os.WriteFile(file+sshsigFileExtension, sig.armor(), 0644)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *gc) sshKeygenCompatVerify(a sshKeygenCompatArgs) error {
+	sig, err := a.readSignature()
+	if err != nil {
+		return err
+	}
+
+	t, err := a.verifyTime()
+	if err != nil {
+		return err
+	}
+
+	data, err := getDataFromStdIn()
+	if err != nil {
+		return err
+	}
+
+	err = sig.verify(a.namespace, bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("could not verify signature: %w", err)
+	}
+
+	signers, err := readAllowedSignersFile(a.file)
+	if err != nil {
+		return err
+	}
+
+	principal, err := signers.findPrincipal(sig.publicKey, a.identity, a.namespace, t)
+	if err != nil {
+		return err
+	}
+
+	fmt.Println(sig.goodMessage(principal))
+	return nil
+}
+
+func (s *gc) sshKeygenCompatFindPrincipals(a sshKeygenCompatArgs) error {
+	sig, err := a.readSignature()
+	if err != nil {
+		return err
+	}
+
+	t, err := a.verifyTime()
+	if err != nil {
+		return err
+	}
+
+	signers, err := readAllowedSignersFile(a.file)
+	if err != nil {
+		return err
+	}
+
+	principals := signers.findPrincipals(sig.publicKey, t)
+	if len(principals) == 0 {
+		return fmt.Errorf("no principal matched for key %s", sig.fingerprint())
+	}
+
+	fmt.Println(strings.Join(principals, "\n"))
+	return nil
+}
+
+func (s *gc) sshKeygenCompatCheckNovalidate(a sshKeygenCompatArgs) error {
+	sig, err := a.readSignature()
+	if err != nil {
+		return err
+	}
+
+	data, err := getDataFromStdIn()
+	if err != nil {
+		return err
+	}
+
+	err = sig.verify(a.namespace, bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("could not verify signature: %w", err)
+	}
+
+	fmt.Println(sig.goodMessage(""))
+	return nil
+}
@@ -3,6 +3,7 @@ package main
 import (
 	"testing"
 
+	"github.com/sethvargo/go-password/password"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -10,8 +11,7 @@ func TestSSHKeygenRsaWoPassword(t *testing.T) {
 	privBytes, pubBytes, err := sshKeygenRsa(2048, "")
 	assert.Nil(t, err)
 
-	agent, err := newSSHAgent()
-	assert.Nil(t, err)
+	agent := newSSHKeyringAgent()
 
 	err = agent.add(privBytes, "", "test ssh key", 30)
 	assert.Nil(t, err)
@@ -21,14 +21,13 @@ func TestSSHKeygenRsaWoPassword(t *testing.T) {
 }
 
 func TestSSHKeygenRsa(t *testing.T) {
-	passwd, err := genPassword(32, true)
+	passwd, err := genPassword(passwordOptions{Mode: passwordModeRandom, Length: 32, Digits: 8, Symbols: 8, SymbolSet: password.Symbols})
 	assert.Nil(t, err)
 
 	privBytes, pubBytes, err := sshKeygenRsa(2048, passwd)
 	assert.Nil(t, err)
 
-	agent, err := newSSHAgent()
-	assert.Nil(t, err)
+	agent := newSSHKeyringAgent()
 
 	err = agent.add(privBytes, passwd, "test ssh key", 30)
 	assert.Nil(t, err)
@@ -41,8 +40,7 @@ func TestSSHKeygenEd25519WoPassword(t *testing.T) {
 	privBytes, pubBytes, err := sshKeygenEd25519("")
 	assert.Nil(t, err)
 
-	agent, err := newSSHAgent()
-	assert.Nil(t, err)
+	agent := newSSHKeyringAgent()
 
 	err = agent.add(privBytes, "", "test ssh key", 30)
 	assert.Nil(t, err)
@@ -52,14 +50,13 @@ func TestSSHKeygenEd25519WoPassword(t *testing.T) {
 }
 
 func TestSSHKeygenEd25519(t *testing.T) {
-	passwd, err := genPassword(32, true)
+	passwd, err := genPassword(passwordOptions{Mode: passwordModeRandom, Length: 32, Digits: 8, Symbols: 8, SymbolSet: password.Symbols})
 	assert.Nil(t, err)
 
 	privBytes, pubBytes, err := sshKeygenEd25519(passwd)
 	assert.Nil(t, err)
 
-	agent, err := newSSHAgent()
-	assert.Nil(t, err)
+	agent := newSSHKeyringAgent()
 
 	err = agent.add(privBytes, passwd, "test ssh key", 30)
 	assert.Nil(t, err)
@@ -0,0 +1,379 @@
+package main
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/urfave/cli/v2"
+	"golang.org/x/crypto/ssh"
+)
+
+// SSHSIG format - https://github.com/openssh/openssh-portable/blob/master/PROTOCOL.sshsig
+const (
+	sshsigMagic         = "SSHSIG"
+	sshsigVersion       = 1
+	sshsigArmorBegin    = "-----BEGIN SSH SIGNATURE-----"
+	sshsigArmorEnd      = "-----END SSH SIGNATURE-----"
+	sshsigArmorWidth    = 70
+	sshsigHashSHA256    = "sha256"
+	sshsigHashSHA512    = "sha512"
+	sshsigFileExtension = ".sig"
+)
+
+type sshSignature struct {
+	publicKey     ssh.PublicKey
+	namespace     string
+	hashAlgorithm string
+	signature     *ssh.Signature
+}
+
+type sshsigBlob struct {
+	Version       uint32
+	PublicKey     []byte
+	Namespace     string
+	Reserved      string
+	HashAlgorithm string
+	Signature     []byte
+}
+
+type sshsigSignedData struct {
+	Namespace     string
+	Reserved      string
+	HashAlgorithm string
+	Hash          []byte
+}
+
+func sshsigHash(alg string) (h hash.Hash, err error) {
+	switch alg {
+	case sshsigHashSHA256:
+		return sha256.New(), nil
+	case sshsigHashSHA512:
+		return sha512.New(), nil
+	default:
+		return nil, fmt.Errorf("unsupported signature hash algorithm '%s'", alg)
+	}
+}
+
+// sshsigData returns data which is actually signed for message
+func sshsigData(namespace, hashAlgorithm string, message io.Reader) (o []byte, err error) {
+	h, err := sshsigHash(hashAlgorithm)
+	if err != nil {
+		return
+	}
+
+	_, err = io.Copy(h, message)
+	if err != nil {
+		return
+	}
+
+	o = append([]byte(sshsigMagic), ssh.Marshal(sshsigSignedData{
+		Namespace:     namespace,
+		HashAlgorithm: hashAlgorithm,
+		Hash:          h.Sum(nil),
+	})...)
+	return
+}
+
+// sshsigSign signs message with signer in namespace
+func sshsigSign(signer ssh.Signer, namespace string, message io.Reader) (sig *sshSignature, err error) {
+	if namespace == "" {
+		return nil, errors.New("signature namespace must be set")
+	}
+
+	data, err := sshsigData(namespace, sshsigHashSHA512, message)
+	if err != nil {
+		return
+	}
+
+	var signature *ssh.Signature
+	if as, ok := signer.(ssh.AlgorithmSigner); ok && signer.PublicKey().Type() == ssh.KeyAlgoRSA {
+		signature, err = as.SignWithAlgorithm(rand.Reader, data, ssh.KeyAlgoRSASHA512)
+	} else {
+		signature, err = signer.Sign(rand.Reader, data)
+	}
+	if err != nil {
+		return
+	}
+
+	return &sshSignature{
+		publicKey:     signer.PublicKey(),
+		namespace:     namespace,
+		hashAlgorithm: sshsigHashSHA512,
+		signature:     signature,
+	}, nil
+}
+
+func (sig *sshSignature) marshal() []byte {
+	return append([]byte(sshsigMagic), ssh.Marshal(sshsigBlob{
+		Version:       sshsigVersion,
+		PublicKey:     sig.publicKey.Marshal(),
+		Namespace:     sig.namespace,
+		HashAlgorithm: sig.hashAlgorithm,
+		Signature:     ssh.Marshal(sig.signature),
+	})...)
+}
+
+// armor returns signature in PEM-like format used by `ssh-keygen -Y sign`
+func (sig *sshSignature) armor() []byte {
+	var buf bytes.Buffer
+	var encoded = base64.StdEncoding.EncodeToString(sig.marshal())
+
+	buf.WriteString(sshsigArmorBegin + "\n")
+	for len(encoded) > sshsigArmorWidth {
+		buf.WriteString(encoded[:sshsigArmorWidth] + "\n")
+		encoded = encoded[sshsigArmorWidth:]
+	}
+	buf.WriteString(encoded + "\n")
+	buf.WriteString(sshsigArmorEnd + "\n")
+
+	return buf.Bytes()
+}
+
+// verify checks signature of message in namespace
+func (sig *sshSignature) verify(namespace string, message io.Reader) (err error) {
+	if sig.namespace != namespace {
+		return fmt.Errorf("signature namespace '%s' does not match expected '%s'", sig.namespace, namespace)
+	}
+
+	data, err := sshsigData(sig.namespace, sig.hashAlgorithm, message)
+	if err != nil {
+		return
+	}
+
+	if sig.publicKey.Type() == ssh.KeyAlgoRSA && sig.signature.Format == ssh.KeyAlgoRSA {
+		return errors.New("signatures with ssh-rsa (SHA1) algorithm are not allowed")
+	}
+
+	return sig.publicKey.Verify(data, sig.signature)
+}
+
+func (sig *sshSignature) fingerprint() string {
+	return ssh.FingerprintSHA256(sig.publicKey)
+}
+
+// goodMessage returns verification result in `ssh-keygen -Y verify` format
+func (sig *sshSignature) goodMessage(principal string) string {
+	if principal == "" {
+		return fmt.Sprintf("Good \"%s\" signature with %s key %s",
+			sig.namespace, getSSHKeyTypeName(sig.publicKey), sig.fingerprint())
+	}
+	return fmt.Sprintf("Good \"%s\" signature for %s with %s key %s",
+		sig.namespace, principal, getSSHKeyTypeName(sig.publicKey), sig.fingerprint())
+}
+
+func parseSSHSignature(data []byte) (sig *sshSignature, err error) {
+	data = bytes.TrimSpace(data)
+	if !bytes.HasPrefix(data, []byte(sshsigArmorBegin)) || !bytes.HasSuffix(data, []byte(sshsigArmorEnd)) {
+		return nil, errors.New("invalid ssh signature armor")
+	}
+
+	data = bytes.TrimPrefix(data, []byte(sshsigArmorBegin))
+	data = bytes.TrimSuffix(data, []byte(sshsigArmorEnd))
+	data = bytes.Join(bytes.Fields(data), nil)
+
+	raw, err := base64.StdEncoding.DecodeString(string(data))
+	if err != nil {
+		return
+	}
+
+	if !bytes.HasPrefix(raw, []byte(sshsigMagic)) {
+		return nil, errors.New("invalid ssh signature magic")
+	}
+
+	var blob sshsigBlob
+	err = ssh.Unmarshal(raw[len(sshsigMagic):], &blob)
+	if err != nil {
+		return
+	}
+
+	if blob.Version != sshsigVersion {
+		return nil, fmt.Errorf("unsupported ssh signature version %d", blob.Version)
+	}
+
+	publicKey, err := ssh.ParsePublicKey(blob.PublicKey)
+	if err != nil {
+		return
+	}
+
+	var signature ssh.Signature
+	err = ssh.Unmarshal(blob.Signature, &signature)
+	if err != nil {
+		return
+	}
+
+	return &sshSignature{
+		publicKey:     publicKey,
+		namespace:     blob.Namespace,
+		hashAlgorithm: blob.HashAlgorithm,
+		signature:     &signature,
+	}, nil
+}
+
+// signResult is structured result of signed data
+type signResult struct {
+	Key           string `json:"key" yaml:"key"`
+	Namespace     string `json:"namespace" yaml:"namespace"`
+	File          string `json:"file,omitempty" yaml:"file,omitempty"`
+	SignatureFile string `json:"signature_file,omitempty" yaml:"signature_file,omitempty"`
+	Signature     string `json:"signature,omitempty" yaml:"signature,omitempty"`
+}
+
+// verifyResult is structured result of verified signature
+type verifyResult struct {
+	Principal   string `json:"principal" yaml:"principal"`
+	Namespace   string `json:"namespace" yaml:"namespace"`
+	Fingerprint string `json:"fingerprint" yaml:"fingerprint"`
+	Message     string `json:"message" yaml:"message"`
+}
+
+// getSigner returns signer for private ssh-key saved in gopass
+func (s *gc) getSigner(key string) (signer ssh.Signer, err error) {
+	s.log().WithField("gkey", key).Info("getting private ssh-key from gopass")
+	privKey, err := s.ks.getPrivateSSHKey(key)
+	if err != nil {
+		return
+	}
+
+	s.log().WithField("gkey", key).Info("getting ssh-key passphrase from gopass")
+	password, err := s.ks.getPassword(key)
+	if err != nil {
+		return
+	}
+
+	rawKey, err := parsePrivateSSHKey(privKey, password)
+	if err != nil {
+		return
+	}
+
+	return ssh.NewSignerFromKey(rawKey)
+}
+
+func readDataFromFileOrStdIn(path string) (o []byte, err error) {
+	if path == "" || path == "-" {
+		return getDataFromStdIn()
+	}
+	return os.ReadFile(path)
+}
+
+// Sign - sign data with ssh-key saved in gopass (`ssh-keygen -Y sign`)
+func (s *gc) Sign(c *cli.Context) error {
+	var store = c.String("store")
+	var namespace = c.String("namespace")
+	var keyPath = c.String("key")
+
+	if keyPath == "" {
+		return newUsageError("ssh-key path must be set")
+	}
+
+	var key = filepath.Join(store, keyPath)
+	signer, err := s.getSigner(key)
+	if err != nil {
+		return err
+	}
+
+	if c.NArg() == 0 {
+		s.log().Info("signing data from stdin")
+		sig, err := sshsigSign(signer, namespace, os.Stdin)
+		if err != nil {
+			return err
+		}
+
+		var armored = string(sig.armor())
+		return s.printResult(signResult{Key: key, Namespace: namespace, Signature: armored}, armored)
+	}
+
+	var o = make([]signResult, 0, c.NArg())
+	for _, file := range c.Args().Slice() {
+		var sigFile = file + sshsigFileExtension
+
+		s.log().Infof("signing file '%s'", file)
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return err
+		}
+
+		sig, err := sshsigSign(signer, namespace, bytes.NewReader(data))
+		if err != nil {
+			return err
+		}
+
+		if _, err := os.Stat(sigFile); err == nil && !s.confirm("Signature file '%s' already exists, overwrite?", sigFile) {
+			continue
+		}
+
+		s.log().Infof("writing signature to '%s'", sigFile)
+		err = os.WriteFile(sigFile, sig.armor(), 0644)
+		if err != nil {
+			return err
+		}
+		o = append(o, signResult{Key: key, Namespace: namespace, File: file, SignatureFile: sigFile})
+	}
+
+	return s.printResult(o, "")
+}
+
+// Verify - verify signature using allowed signers file (`ssh-keygen -Y verify`)
+func (s *gc) Verify(c *cli.Context) error {
+	var namespace = c.String("namespace")
+	var allowedSignersFile = c.String("allowed-signers")
+	var identity = c.String("identity")
+	var file = c.Args().Get(0)
+	var sigFile = c.String("signature")
+
+	if sigFile == "" {
+		if file == "" || file == "-" {
+			return newUsageError("signature file must be set")
+		}
+		sigFile = file + sshsigFileExtension
+	}
+
+	s.log().Infof("reading signature from '%s'", sigFile)
+	sigData, err := os.ReadFile(sigFile)
+	if err != nil {
+		return err
+	}
+
+	sig, err := parseSSHSignature(sigData)
+	if err != nil {
+		return err
+	}
+
+	data, err := readDataFromFileOrStdIn(file)
+	if err != nil {
+		return err
+	}
+
+	err = sig.verify(namespace, bytes.NewReader(data))
+	if err != nil {
+		return newAppError(errCodeVerification, fmt.Errorf("signature verification failed: %w", err))
+	}
+
+	s.log().Infof("reading allowed signers from '%s'", allowedSignersFile)
+	signers, err := readAllowedSignersFile(allowedSignersFile)
+	if err != nil {
+		return err
+	}
+
+	principal, err := signers.findPrincipal(sig.publicKey, identity, namespace, time.Now())
+	if err != nil {
+		return newAppError(errCodeVerification, err)
+	}
+
+	var msg = sig.goodMessage(principal)
+	return s.printResult(verifyResult{
+		Principal:   principal,
+		Namespace:   namespace,
+		Fingerprint: sig.fingerprint(),
+		Message:     msg,
+	}, msg+"\n")
+}
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/ssh"
+)
+
+func testSigner(t *testing.T, ttype string) (ssh.Signer, []byte) {
+	privBytes, pubBytes, err := sshKeygen(ttype, "", 2048)
+	assert.Nil(t, err)
+
+	signer, err := ssh.ParsePrivateKey(privBytes)
+	assert.Nil(t, err)
+
+	return signer, pubBytes
+}
+
+func TestSSHSigSignVerify(t *testing.T) {
+	for _, ttype := range []string{sshKeyTypeEd25519, sshKeyTypeRsa} {
+		signer, pubBytes := testSigner(t, ttype)
+		message := []byte("signed message")
+
+		sig, err := sshsigSign(signer, "git", bytes.NewReader(message))
+		assert.Nil(t, err)
+
+		parsed, err := parseSSHSignature(sig.armor())
+		assert.Nil(t, err)
+		assert.Nil(t, parsed.verify("git", bytes.NewReader(message)))
+		assert.NotNil(t, parsed.verify("file", bytes.NewReader(message)))
+		assert.NotNil(t, parsed.verify("git", bytes.NewReader([]byte("other message"))))
+
+		signers, err := parseAllowedSigners([]byte(fmt.Sprintf("*@example.com namespaces=\"git\" %s", pubBytes)))
+		assert.Nil(t, err)
+
+		principal, err := signers.findPrincipal(parsed.publicKey, "dev@example.com", "git", time.Now())
+		assert.Nil(t, err)
+		assert.Equal(t, "dev@example.com", principal)
+
+		_, err = signers.findPrincipal(parsed.publicKey, "dev@example.org", "git", time.Now())
+		assert.NotNil(t, err)
+
+		_, err = signers.findPrincipal(parsed.publicKey, "dev@example.com", "file", time.Now())
+		assert.NotNil(t, err)
+	}
+}
+
+func TestAllowedSignersParse(t *testing.T) {
+	_, pubBytes := testSigner(t, sshKeyTypeEd25519)
+	line := fmt.Sprintf("\"dev@example.com,ops@example.com\" namespaces=\"git,file\",valid-after=\"20230101\",valid-before=\"20240101Z\" %s",
+		strings.TrimSpace(string(pubBytes)))
+
+	signers, err := parseAllowedSigners([]byte("# comment\n\n" + line))
+	assert.Nil(t, err)
+	assert.Len(t, signers, 1)
+
+	as := signers[0]
+	assert.Equal(t, []string{"dev@example.com", "ops@example.com"}, as.principals)
+	assert.Equal(t, []string{"git", "file"}, as.namespaces)
+	assert.Equal(t, time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), as.validBefore)
+	assert.False(t, as.validAt(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)))
+
+	reparsed, err := parseAllowedSigners([]byte(as.String()))
+	assert.Nil(t, err)
+	assert.Equal(t, as.String(), reparsed[0].String())
+}
+
+func TestSSHKeygenCompatArgs(t *testing.T) {
+	args := []string{"-Y", "verify", "-n", "git", "-f", "allowed_signers", "-Iuser@example.com",
+		"-s", "file.sig", "-Overify-time=20230102030405", "-U", "file"}
+	assert.True(t, isSSHKeygenCompatCall(args))
+
+	a, err := parseSSHKeygenCompatArgs(args)
+	assert.Nil(t, err)
+	assert.Equal(t, "verify", a.operation)
+	assert.Equal(t, "git", a.namespace)
+	assert.Equal(t, "allowed_signers", a.file)
+	assert.Equal(t, "user@example.com", a.identity)
+	assert.Equal(t, "file.sig", a.signature)
+	assert.True(t, a.useAgent)
+	assert.Equal(t, []string{"file"}, a.args)
+
+	vt, err := a.verifyTime()
+	assert.Nil(t, err)
+	assert.Equal(t, 2023, vt.Year())
+
+	_, err = parseSSHKeygenCompatArgs([]string{"-Y", "sign", "-n"})
+	assert.NotNil(t, err)
+}
+
+func TestSSHKeygenCompatPublicKey(t *testing.T) {
+	privBytes, pubBytes, err := sshKeygen("ed25519", "secret", 0)
+	assert.Nil(t, err)
+
+	var dir = t.TempDir()
+	var privFile = dir + "/id_ed25519"
+	assert.Nil(t, os.WriteFile(privFile, privBytes, 0600))
+
+	// encrypted private key is read without passphrase
+	key, err := readSSHKeygenCompatPublicKey(privFile)
+	assert.Nil(t, err)
+	assert.Equal(t, strings.TrimSpace(string(pubBytes)), strings.TrimSpace(string(ssh.MarshalAuthorizedKey(key))))
+
+	key, err = readSSHKeygenCompatPublicKey(sshKeygenCompatLiteralKeyPrefix + string(pubBytes))
+	assert.Nil(t, err)
+	assert.Equal(t, "ssh-ed25519", key.Type())
+
+	_, err = readSSHKeygenCompatPublicKey(sshKeygenCompatLiteralKeyPrefix + "invalid")
+	assert.ErrorContains(t, err, "cannot parse signing key")
+}
+
+func TestCommandsAllowedSignersBuild(t *testing.T) {
+	_, ms, run := newTestGc()
+
+	for _, args := range [][]string{
+		{"secret", "generate", "--expires", "2030-01-02", "dev/alice"},
+		{"secret", "metadata", "set", "dev/alice", "email=alice@example.com", "namespaces=git,file"},
+		{"secret", "generate", "devops/bob"},
+		{"secret", "metadata", "set", "devops/bob", "principals=bob@example.com,ops@example.com"},
+		{"secret", "generate", "dev/nobody"},
+	} {
+		_, err := run(args...)
+		assert.Nil(t, err)
+	}
+
+	var created = mustParseMetadataTime(t, ms.metadata["ssh-keys/dev/alice"][gopassMetaCreated])
+	var alicePubKey = strings.TrimSpace(string(ms.publicKeys["ssh-keys/dev/alice"]))
+	var aliceLine = fmt.Sprintf(`alice@example.com namespaces="git,file",valid-after="%s",valid-before="20300102000000Z" %s gssh:ssh-keys/dev/alice`,
+		formatAllowedSignersTime(created), alicePubKey)
+
+	// principals fall back to email, ssh-key without principals is skipped, prefix matches path segments
+	out, err := run("allowed-signers", "build", "--prefix", "dev")
+	assert.Nil(t, err)
+	assert.Equal(t, aliceLine+"\n", out)
+
+	out, err = run("allowed-signers", "build", "--prefix", "dev", "--validity=false")
+	assert.Nil(t, err)
+	assert.Equal(t, fmt.Sprintf(`alice@example.com namespaces="git,file" %s gssh:ssh-keys/dev/alice`, alicePubKey)+"\n", out)
+
+	var file = filepath.Join(t.TempDir(), "allowed_signers")
+	_, err = run("allowed-signers", "build", "--file", file)
+	assert.Nil(t, err)
+
+	data, err := os.ReadFile(file)
+	assert.Nil(t, err)
+	assert.Equal(t, aliceLine+"\n"+fmt.Sprintf(`bob@example.com,ops@example.com valid-after="%s" %s gssh:ssh-keys/devops/bob`,
+		formatAllowedSignersTime(mustParseMetadataTime(t, ms.metadata["ssh-keys/devops/bob"][gopassMetaCreated])),
+		strings.TrimSpace(string(ms.publicKeys["ssh-keys/devops/bob"])))+"\n", string(data))
+}
+
+func mustParseMetadataTime(t *testing.T, in string) time.Time {
+	v, err := parseMetadataTime(in)
+	assert.Nil(t, err)
+	return v
+}
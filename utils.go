@@ -2,17 +2,21 @@ package main
 
 import (
 	"bufio"
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/rsa"
 	"crypto/sha256"
 	"encoding/base64"
 	"errors"
 	"fmt"
 	"io"
-	"math"
 	"os"
+	"path/filepath"
+	"strings"
 
 	"github.com/gopasspw/gopass/pkg/gopass"
 	"github.com/manifoldco/promptui"
-	"github.com/sethvargo/go-password/password"
+	"golang.org/x/crypto/ssh"
 )
 
 func getHashFromBytes(in []byte) string {
@@ -34,8 +38,8 @@ func gopassSecretsDiff(a, b gopass.Byter) bool {
 func base64Decode(in []byte) (out []byte, err error) {
 	var sizeDecoded = base64.StdEncoding.DecodedLen(len(in))
 	out = make([]byte, sizeDecoded)
-	_, err = base64.StdEncoding.Decode(out, in)
-	return
+	n, err := base64.StdEncoding.Decode(out, in)
+	return out[:n], err
 }
 
 func base64Encode(in []byte) (out []byte, err error) {
@@ -58,26 +62,12 @@ func getDataFromStdIn() (out []byte, err error) {
 	return
 }
 
-func genPassword(length int, symbols bool) (out string, err error) {
-	if length < 16 {
-		return "", errors.New("password is too short")
-	}
-
-	var symbolsNum = int(math.Trunc(float64(length) / 4))
-	var digitsNum = int(math.Trunc(float64(length) / 4))
-
-	out, err = password.Generate(length, digitsNum, symbolsNum, true, false)
-	if err != nil {
-		return
-	}
-	return
-}
-
 func askForConfirmation(s string, ss ...any) bool {
 	var err error
 	p := promptui.Prompt{
 		Label:     fmt.Sprintf(s, ss...),
 		IsConfirm: true,
+		Stdout:    os.Stderr,
 	}
 
 	_, err = p.Run()
@@ -94,3 +84,87 @@ func askForConfirmation(s string, ss ...any) bool {
 func getSSHKeyComment(key string) string {
 	return fmt.Sprintf("%s:%s", "gssh", key)
 }
+
+// hasPathPrefix checks path is prefix or is under it, prefix is compared by path segments
+func hasPathPrefix(path, prefix string) bool {
+	prefix = strings.Trim(prefix, "/")
+	return prefix == "" || path == prefix || strings.HasPrefix(path, prefix+"/")
+}
+
+// expandHomeDir replaces leading `~` in path with user home directory
+func expandHomeDir(path string) string {
+	if path != "~" && !strings.HasPrefix(path, "~/") {
+		return path
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return path
+	}
+	return filepath.Join(home, strings.TrimPrefix(path, "~"))
+}
+
+// isSSHKeyData checks data is PEM/OpenSSH private key or authorized_keys public key
+func isSSHKeyData(data []byte) bool {
+	data = bytes.TrimSpace(data)
+	if bytes.HasPrefix(data, []byte("-----BEGIN ")) {
+		return true
+	}
+
+	_, _, _, _, err := ssh.ParseAuthorizedKey(data)
+	return err == nil
+}
+
+// getSSHKeyFingerprint returns SHA256 fingerprint of authorized_keys public key, empty string if key is invalid
+func getSSHKeyFingerprint(pubKey []byte) string {
+	key, _, _, _, err := ssh.ParseAuthorizedKey(pubKey)
+	if err != nil {
+		return ""
+	}
+	return ssh.FingerprintSHA256(key)
+}
+
+// getSSHKeyTypeName returns key type name as shown by openssh tools (ED25519, RSA, ...)
+func getSSHKeyTypeName(key ssh.PublicKey) string {
+	var t = key.Type()
+	if cert, ok := key.(*ssh.Certificate); ok {
+		t = cert.Key.Type()
+	}
+
+	switch {
+	case strings.HasPrefix(t, "sk-ssh-ed25519"):
+		return "ED25519-SK"
+	case strings.HasPrefix(t, "sk-ecdsa-"):
+		return "ECDSA-SK"
+	case strings.HasPrefix(t, "ecdsa-"):
+		return "ECDSA"
+	case t == ssh.KeyAlgoED25519:
+		return "ED25519"
+	case t == ssh.KeyAlgoRSA:
+		return "RSA"
+	case t == ssh.KeyAlgoDSA:
+		return "DSA"
+	default:
+		return strings.ToUpper(t)
+	}
+}
+
+// getSSHKeyBits returns ssh-key size in bits, 0 if it is unknown
+func getSSHKeyBits(pubKey ssh.PublicKey) int {
+	cpk, ok := pubKey.(ssh.CryptoPublicKey)
+	if !ok {
+		return 0
+	}
+
+	switch k := cpk.CryptoPublicKey().(type) {
+	case *rsa.PublicKey:
+		return k.N.BitLen()
+	case *ecdsa.PublicKey:
+		return k.Curve.Params().BitSize
+	}
+
+	if pubKey.Type() == ssh.KeyAlgoED25519 {
+		return 256
+	}
+	return 0
+}